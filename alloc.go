@@ -0,0 +1,26 @@
+package defaults
+
+// AllocPolicy controls whether Defaults allocates nil struct pointer fields
+// in order to apply the default tags of the nested struct.
+type AllocPolicy int
+
+const (
+	// AllocAlways allocates every nil struct pointer and applies its defaults.
+	AllocAlways AllocPolicy = iota
+	// AllocIfDefaulted allocates a nil struct pointer only if at least one
+	// nested field actually receives a default, or a nested struct has a
+	// SetDefaults method (see Setter).
+	AllocIfDefaulted
+	// AllocNever leaves nil struct pointers nil.
+	AllocNever
+)
+
+// SetAllocPolicy sets the policy used by Defaults for nil struct pointer fields.
+// Use WithAllocPolicy to configure a Defaulter instead.
+// It can be overridden per field with the `alloc=always|defaulted|never`
+// tag option, or `noalloc` as a shorthand for `alloc=never`:
+//
+//	TLS *TLSConfig `default:",alloc=defaulted"`
+func SetAllocPolicy(p AllocPolicy) {
+	std.alloc = p
+}
@@ -0,0 +1,92 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Nested struct without default tags
+type testTLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Nested struct with default tags
+type testServer struct {
+	Port int `default:"8080"`
+}
+
+// Struct for testing the global alloc policy
+type testAlloc struct {
+	TLS    *testTLS
+	Server *testServer
+}
+
+// Struct for testing per-field alloc options
+type testAllocTag struct {
+	TLS       *testTLS    `default:",alloc=defaulted"`
+	Server    *testServer `default:",alloc=defaulted"`
+	NoServer  *testServer `default:",noalloc"`
+	Always    *testTLS    `default:",alloc=always"`
+	Literal   *testServer `default:"{\"Port\":9090},noalloc"`
+	Unchanged *testServer `default:",noalloc"`
+}
+
+func TestAllocPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy AllocPolicy
+		input  any
+		want   any
+	}{
+		{
+			name:   "always allocate",
+			policy: AllocAlways,
+			input:  &testAlloc{},
+			want:   &testAlloc{TLS: &testTLS{}, Server: &testServer{Port: 8080}},
+		},
+		{
+			name:   "allocate if defaulted",
+			policy: AllocIfDefaulted,
+			input:  &testAlloc{},
+			want:   &testAlloc{Server: &testServer{Port: 8080}},
+		},
+		{
+			name:   "never allocate",
+			policy: AllocNever,
+			input:  &testAlloc{},
+			want:   &testAlloc{},
+		},
+		{
+			name:   "never allocate still defaults existing pointers",
+			policy: AllocNever,
+			input:  &testAlloc{Server: &testServer{}},
+			want:   &testAlloc{Server: &testServer{Port: 8080}},
+		},
+		{
+			name:   "tag options override the policy",
+			policy: AllocNever,
+			input:  &testAllocTag{Unchanged: &testServer{}},
+			want: &testAllocTag{
+				Server:    &testServer{Port: 8080},
+				Always:    &testTLS{},
+				Literal:   &testServer{Port: 9090},
+				Unchanged: &testServer{Port: 8080},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAllocPolicy(tt.policy)
+			t.Cleanup(func() { SetAllocPolicy(AllocAlways) })
+
+			if err := Defaults(tt.input); err != nil {
+				t.Fatalf("SetDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,816 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/types"
+	"math"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+
+	defaults "github.com/lthphuw/go-defaults"
+	"github.com/lthphuw/go-defaults/internal/typeconv"
+)
+
+// generate loads the package matching patterns and returns the output file name
+// and the formatted source of the generated methods for the named types.
+func generate(names []string, patterns []string, tag, output string) (string, []byte, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return "", nil, err
+	}
+	pkg, objs, err := findTypes(pkgs, names)
+	if err != nil {
+		return "", nil, err
+	}
+
+	g := &generator{
+		pkg:     pkg.Types,
+		tag:     tag,
+		conv:    typeconv.New(),
+		imports: make(map[string]string),
+	}
+	for _, obj := range objs {
+		if err := g.generateType(obj); err != nil {
+			return "", nil, err
+		}
+	}
+
+	file := pkg.Fset.Position(objs[0].Pos()).Filename
+	if output == "" {
+		suffix := "_defaults.go"
+		if strings.HasSuffix(file, "_test.go") {
+			suffix = "_defaults_test.go"
+		}
+		output = filepath.Join(filepath.Dir(file), strings.ToLower(names[0])+suffix)
+	}
+	src, err := g.source(names)
+	return output, src, err
+}
+
+// findTypes returns the package declaring the named types, preferring the package
+// itself over its test variants, together with the type names.
+func findTypes(pkgs []*packages.Package, names []string) (*packages.Package, []*types.TypeName, error) {
+	// Test variants have IDs such as "p [p.test]"; try the package itself first
+	slices.SortStableFunc(pkgs, func(a, b *packages.Package) int {
+		return strings.Count(a.ID, " ") - strings.Count(b.ID, " ")
+	})
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		var objs []*types.TypeName
+		for _, name := range names {
+			obj, ok := pkg.Types.Scope().Lookup(strings.TrimSpace(name)).(*types.TypeName)
+			if !ok {
+				break
+			}
+			objs = append(objs, obj)
+		}
+		if len(objs) == len(names) {
+			return pkg, objs, nil
+		}
+	}
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, fmt.Errorf("types %s not found", strings.Join(names, ", "))
+}
+
+// generator accumulates the generated code for the types of a package.
+type generator struct {
+	pkg     *types.Package
+	tag     string
+	conv    *typeconv.Converter
+	imports map[string]string // import path -> package name
+
+	methods bytes.Buffer
+	helpers []*bytes.Buffer
+	names   typeutil.Map // struct type -> helper function name
+	used    map[string]bool
+	pending typeutil.Map // struct types whose helper is being generated
+}
+
+// generateType generates the SetDefaults and DefaultsTag methods of a named struct type.
+func (g *generator) generateType(obj *types.TypeName) error {
+	named, ok := obj.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() > 0 {
+		return fmt.Errorf("%s: only non-generic named struct types are supported", obj.Name())
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("%s: not a struct type", obj.Name())
+	}
+	helper, err := g.helper(named, exportName(obj.Name()), obj.Name())
+	if err != nil {
+		return err
+	}
+
+	w := &g.methods
+	fmt.Fprintf(w, "// SetDefaults sets the fields of x that are unset to the values of their %s tags.\n", g.tag)
+	fmt.Fprintf(w, "func (x *%s) SetDefaults() error {\n", obj.Name())
+	fmt.Fprintf(w, "\t%s(x)\n", helper)
+	fmt.Fprintf(w, "\treturn nil\n}\n\n")
+	fmt.Fprintf(w, "// DefaultsTag returns the struct tag key SetDefaults was generated from.\n")
+	fmt.Fprintf(w, "func (*%s) DefaultsTag() string {\n", obj.Name())
+	fmt.Fprintf(w, "\treturn %q\n}\n\n", g.tag)
+	return nil
+}
+
+// helper returns the name of the function setting the defaults of struct type t,
+// generating it on first use. The function reports whether any field was set.
+// hint is used to name the function and path to report errors.
+func (g *generator) helper(t types.Type, hint, path string) (string, error) {
+	if g.pending.At(t) != nil {
+		return "", fmt.Errorf("%s: recursive type %s is not supported", path, g.typeString(t))
+	}
+	if name, ok := g.names.At(t).(string); ok {
+		return name, nil
+	}
+	if typeconv.HasMethod(t, "SetDefaults") && !typeconv.HasMethod(t, "DefaultsTag") {
+		return "", fmt.Errorf("%s: type %s has a SetDefaults method, which is not supported", path, g.typeString(t))
+	}
+	g.pending.Set(t, true)
+	defer g.pending.Delete(t)
+
+	name := "setDefaults" + hint
+	if g.used == nil {
+		g.used = make(map[string]bool)
+	}
+	for i := 2; g.used[name]; i++ {
+		name = "setDefaults" + hint + strconv.Itoa(i)
+	}
+	g.used[name] = true
+	g.names.Set(t, name)
+
+	w := new(bytes.Buffer)
+	g.helpers = append(g.helpers, w)
+	fmt.Fprintf(w, "// %s sets the unset fields of x to their defaults and reports whether any was set.\n", name)
+	fmt.Fprintf(w, "func %s(x *%s) (changed bool) {\n", name, g.typeString(t))
+	st := t.Underlying().(*types.Struct)
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(st.Tag(i)).Get(g.tag)
+		if err := g.field(w, hint, path+"."+field.Name(), field, tag); err != nil {
+			return "", err
+		}
+	}
+	fmt.Fprintf(w, "\treturn changed\n}\n\n")
+	return name, nil
+}
+
+// field generates the code setting the default of a single field.
+func (g *generator) field(w *bytes.Buffer, hint, path string, field *types.Var, tag string) error {
+	value, opts, err := splitTag(tag, field.Type())
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if strings.Contains(value, "${") || strings.Contains(value, "$$") {
+		return fmt.Errorf("%s: ${VAR} references are resolved at run time and not supported by defaults-gen", path)
+	}
+	if strings.HasPrefix(value, "@") && !strings.HasPrefix(value, "@@") {
+		return fmt.Errorf("%s: providers are called at run time and not supported by defaults-gen", path)
+	}
+	expr := "x." + field.Name()
+	t := field.Type()
+
+	// Nested structs and struct pointers, unless parsed by their own methods
+	if elem, ptr, ok := nestedStruct(t, value != ""); ok {
+		if value != "" {
+			return fmt.Errorf("%s: JSON defaults on struct fields are not supported", path)
+		}
+		helper, err := g.helper(elem, g.helperHint(hint, elem, field.Name()), path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !ptr:
+			fmt.Fprintf(w, "\tif %s(&%s) {\n\t\tchanged = true\n\t}\n", helper, expr)
+		case opts.alloc == defaults.AllocNever:
+			fmt.Fprintf(w, "\tif %s != nil && %s(%s) {\n\t\tchanged = true\n\t}\n", expr, helper, expr)
+		case opts.alloc == defaults.AllocIfDefaulted:
+			fmt.Fprintf(w, "\tif %s == nil {\n", expr)
+			fmt.Fprintf(w, "\t\tif v := new(%s); %s(v) {\n\t\t\t%s = v\n\t\t\tchanged = true\n\t\t}\n", g.typeString(elem), helper, expr)
+			fmt.Fprintf(w, "\t} else if %s(%s) {\n\t\tchanged = true\n\t}\n", helper, expr)
+		default:
+			fmt.Fprintf(w, "\tif %s == nil {\n\t\t%s = new(%s)\n\t}\n", expr, expr, g.typeString(elem))
+			fmt.Fprintf(w, "\tif %s(%s) {\n\t\tchanged = true\n\t}\n", helper, expr)
+		}
+		return nil
+	}
+
+	if value != "" {
+		if err := g.leaf(w, path, expr, t, value, opts); err != nil {
+			return err
+		}
+	}
+
+	// Struct elements of slices, arrays and maps, including those just set
+	elem, ptr, ok := structElems(t, value != "")
+	if !ok {
+		return nil
+	}
+	helper, err := g.helper(elem, g.helperHint(hint, elem, field.Name()), path)
+	if err != nil {
+		return err
+	}
+	_, isMap := t.Underlying().(*types.Map)
+	switch {
+	case ptr:
+		fmt.Fprintf(w, "\tfor _, v := range %s {\n\t\tif v != nil && %s(v) {\n\t\t\tchanged = true\n\t\t}\n\t}\n", expr, helper)
+	case isMap:
+		fmt.Fprintf(w, "\tfor k, v := range %s {\n\t\tif %s(&v) {\n\t\t\t%s[k] = v\n\t\t\tchanged = true\n\t\t}\n\t}\n", expr, helper, expr)
+	default:
+		fmt.Fprintf(w, "\tfor i := range %s {\n\t\tif %s(&%s[i]) {\n\t\t\tchanged = true\n\t\t}\n\t}\n", expr, helper, expr)
+	}
+	return nil
+}
+
+// leaf generates the code setting the field expr of type t to value when the policy option allows it.
+func (g *generator) leaf(w *bytes.Buffer, path, expr string, t types.Type, value string, opts tagOptions) error {
+	rt := g.conv.ReflectType(t)
+	if rt == nil {
+		return fmt.Errorf("%s: type %s is not supported by defaults-gen", path, g.typeString(t))
+	}
+	val, err := parse(rt, value, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var cond string
+	switch opts.policy {
+	case defaults.ApplyAlways:
+	case defaults.ApplyIfNil:
+		if !isNillable(t) {
+			return nil
+		}
+		cond = expr + " == nil"
+	case defaults.ApplyNever:
+		return nil
+	default:
+		if cond, err = g.unsetExpr(expr, t); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// Pointers are set through a variable v, which needs a block of its own
+	block := cond != "" || isPointer(t)
+	indent := "\t"
+	if block {
+		if cond != "" {
+			fmt.Fprintf(w, "\tif %s {\n", cond)
+		} else {
+			fmt.Fprintf(w, "\t{\n")
+		}
+		indent = "\t\t"
+	}
+	switch {
+	case isOpt(t):
+		lit, err := g.literal(t.(*types.Named).TypeArgs().At(0), val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(w, "%s%s.Set(%s)\n", indent, expr, lit)
+	case isPointer(t):
+		lit, err := g.literal(t.Underlying().(*types.Pointer).Elem(), val.Elem())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(w, "%sv := %s\n%s%s = &v\n", indent, lit, indent, expr)
+	default:
+		lit, err := g.literal(t, val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(w, "%s%s = %s\n", indent, expr, lit)
+	}
+	fmt.Fprintf(w, "%schanged = true\n", indent)
+	if block {
+		fmt.Fprintf(w, "\t}\n")
+	}
+	return nil
+}
+
+// helperHint returns the name hint for the helper of a nested struct type:
+// the root hint followed by the type name, or the field name for unnamed structs.
+func (g *generator) helperHint(hint string, t types.Type, field string) string {
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		return hint + exportName(named.Obj().Name())
+	}
+	return hint + exportName(field)
+}
+
+// parse parses a default tag value for a field of type rt with the parsers of the defaults package.
+func parse(rt reflect.Type, tag string, opts tagOptions) (reflect.Value, error) {
+	holder := reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: rt,
+		Tag:  reflect.StructTag("default:" + strconv.Quote(tag)),
+	}})
+	v := reflect.New(holder)
+	d := defaults.New(defaults.WithSyntax(opts.syntax), defaults.WithQuantitySyntax(opts.quantity))
+	if err := d.Apply(v.Interface()); err != nil {
+		var fe *defaults.FieldError
+		if errors.As(err, &fe) {
+			return reflect.Value{}, fe.Err
+		}
+		return reflect.Value{}, err
+	}
+	return v.Elem().Field(0), nil
+}
+
+// unsetExpr returns the condition under which the field expr of type t is unset,
+// following the rules of Defaults with its default settings.
+func (g *generator) unsetExpr(expr string, t types.Type) (string, error) {
+	switch {
+	case isPointer(t):
+		return expr + " == nil", nil
+	case isOpt(t):
+		return "!" + expr + ".IsSet()", nil
+	case typeconv.HasMethod(t, "IsSet"):
+		return "", errors.New("Presence types other than Opt are not supported by defaults-gen")
+	case typeconv.HasMethod(t, "IsZero"):
+		return expr + ".IsZero()", nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Map, *types.Slice:
+		return expr + " == nil", nil
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "!" + expr, nil
+		case u.Info()&types.IsString != 0:
+			return expr + ` == ""`, nil
+		case u.Info()&types.IsNumeric != 0:
+			return expr + " == 0", nil
+		}
+	case *types.Array:
+		if types.Comparable(t) {
+			return fmt.Sprintf("%s == (%s{})", expr, g.typeString(t)), nil
+		}
+	}
+	return "", fmt.Errorf("type %s is not supported by defaults-gen", g.typeString(t))
+}
+
+// literal returns a Go expression of type t with the value v.
+func (g *generator) literal(t types.Type, v reflect.Value) (string, error) {
+	if named, ok := types.Unalias(t).(*types.Named); ok {
+		if isOpt(named) || isTimeType(named, "Location") || hasUnmarshaler(named) {
+			return "", fmt.Errorf("type %s is not supported by defaults-gen", g.typeString(t))
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		lit, err := g.basicLiteral(v)
+		if err != nil {
+			return "", err
+		}
+		if types.Identical(t, types.Default(untypedKind(u))) {
+			return lit, nil
+		}
+		return g.typeString(t) + "(" + lit + ")", nil
+	case *types.Pointer:
+		if v.IsNil() {
+			return "(" + g.typeString(t) + ")(nil)", nil
+		}
+		elem, err := g.literal(u.Elem(), v.Elem())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("func() %s { v := %s; return &v }()", g.typeString(t), elem), nil
+	case *types.Slice:
+		if v.IsNil() {
+			return g.typeString(t) + "(nil)", nil
+		}
+		return g.listLiteral(t, u.Elem(), v)
+	case *types.Array:
+		return g.listLiteral(t, u.Elem(), v)
+	case *types.Map:
+		if v.IsNil() {
+			return g.typeString(t) + "(nil)", nil
+		}
+		entries := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := g.literal(u.Key(), iter.Key())
+			if err != nil {
+				return "", err
+			}
+			elem, err := g.literal(u.Elem(), iter.Value())
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, key+": "+elem)
+		}
+		slices.Sort(entries)
+		return g.typeString(t) + "{" + strings.Join(entries, ", ") + "}", nil
+	case *types.Struct:
+		var fields []string
+		for i := range u.NumFields() {
+			field := u.Field(i)
+			if !field.Exported() || v.Field(i).IsZero() {
+				continue
+			}
+			lit, err := g.literal(field.Type(), v.Field(i))
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, field.Name()+": "+lit)
+		}
+		return g.typeString(t) + "{" + strings.Join(fields, ", ") + "}", nil
+	case *types.Interface:
+		if v.IsNil() {
+			return "nil", nil
+		}
+		return g.dynamicLiteral(v.Elem())
+	}
+	return "", fmt.Errorf("type %s is not supported by defaults-gen", g.typeString(t))
+}
+
+// listLiteral returns a composite literal of slice or array type t.
+func (g *generator) listLiteral(t, elem types.Type, v reflect.Value) (string, error) {
+	elems := make([]string, v.Len())
+	for i := range elems {
+		lit, err := g.literal(elem, v.Index(i))
+		if err != nil {
+			return "", err
+		}
+		elems[i] = lit
+	}
+	return g.typeString(t) + "{" + strings.Join(elems, ", ") + "}", nil
+}
+
+// dynamicLiteral returns a Go expression for a value decoded from JSON into an interface,
+// keeping its dynamic type.
+func (g *generator) dynamicLiteral(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil", nil
+		}
+		return g.dynamicLiteral(v.Elem())
+	case reflect.Bool, reflect.String, reflect.Float64:
+		return g.basicLiteral(v)
+	case reflect.Slice:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			lit, err := g.dynamicLiteral(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			elems[i] = lit
+		}
+		return "[]any{" + strings.Join(elems, ", ") + "}", nil
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			elem, err := g.dynamicLiteral(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, strconv.Quote(iter.Key().String())+": "+elem)
+		}
+		slices.Sort(entries)
+		return "map[string]any{" + strings.Join(entries, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("value of type %s is not supported by defaults-gen", v.Type())
+}
+
+// basicLiteral returns the untyped constant for a boolean, numeric or string value.
+// Floats always carry a decimal point or exponent so that they default to float64.
+func (g *generator) basicLiteral(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.String:
+		return strconv.Quote(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return g.floatLiteral(v.Float(), v.Type().Bits()), nil
+	case reflect.Complex64, reflect.Complex128:
+		c, bits := v.Complex(), v.Type().Bits()/2
+		return fmt.Sprintf("complex(%s, %s)", g.floatLiteral(real(c), bits), g.floatLiteral(imag(c), bits)), nil
+	}
+	return "", fmt.Errorf("value of type %s is not supported by defaults-gen", v.Type())
+}
+
+func (g *generator) floatLiteral(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		g.imports["math"] = "math"
+		return "math.NaN()"
+	case math.IsInf(f, 0):
+		g.imports["math"] = "math"
+		return fmt.Sprintf("math.Inf(%d)", int(math.Copysign(1, f)))
+	}
+	lit := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(lit, ".e") {
+		lit += ".0"
+	}
+	return lit
+}
+
+// typeString returns t as written in the generated file, recording the imports it needs.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// source returns the formatted generated file.
+func (g *generator) source(names []string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by \"defaults-gen -type=%s", strings.Join(names, ","))
+	if g.tag != "default" {
+		fmt.Fprintf(&b, " -tag=%s", g.tag)
+	}
+	fmt.Fprintf(&b, "\"; DO NOT EDIT.\n\npackage %s\n\n", g.pkg.Name())
+	if len(g.imports) == 1 {
+		for p, name := range g.imports {
+			b.WriteString("import " + importSpec(name, p) + "\n\n")
+		}
+	} else if len(g.imports) > 1 {
+		paths := make([]string, 0, len(g.imports))
+		for p := range g.imports {
+			paths = append(paths, p)
+		}
+		slices.Sort(paths)
+		b.WriteString("import (\n")
+		for _, p := range paths {
+			b.WriteString("\t" + importSpec(g.imports[p], p) + "\n")
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(g.methods.Bytes())
+	for _, h := range g.helpers {
+		b.Write(h.Bytes())
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// importSpec returns the import spec of the package with the given name and path.
+func importSpec(name, p string) string {
+	if name != path.Base(p) {
+		return name + " " + strconv.Quote(p)
+	}
+	return strconv.Quote(p)
+}
+
+// tagOptions holds the options following the value of a default tag.
+type tagOptions struct {
+	alloc    defaults.AllocPolicy
+	policy   defaults.ApplyPolicy
+	syntax   defaults.Syntax
+	quantity bool
+}
+
+// splitTag splits the tag of a field of type t into its value and options, following
+// the rules of Defaults: options are recognized from the end of the tag, the last
+// occurrence wins, quantity only applies to integer and float fields and syntax
+// only to slice, array and map fields.
+func splitTag(tag string, t types.Type) (string, tagOptions, error) {
+	var opts tagOptions
+	seen := make(map[string]bool)
+	for {
+		i := strings.LastIndexByte(tag, ',')
+		if i < 0 {
+			return tag, opts, nil
+		}
+		name, arg, hasArg := strings.Cut(tag[i+1:], "=")
+		switch {
+		case name == "noalloc" && !hasArg, name == "alloc" && hasArg:
+			a := defaults.AllocNever
+			switch {
+			case !hasArg, arg == "never":
+			case arg == "always":
+				a = defaults.AllocAlways
+			case arg == "defaulted":
+				a = defaults.AllocIfDefaulted
+			default:
+				return "", opts, fmt.Errorf("invalid tag option alloc=%s", arg)
+			}
+			if !seen["alloc"] {
+				opts.alloc = a
+			}
+			name = "alloc"
+		case name == "policy" && hasArg:
+			var p defaults.ApplyPolicy
+			switch arg {
+			case "ifzero":
+				p = defaults.ApplyIfZero
+			case "always":
+				p = defaults.ApplyAlways
+			case "ifnil":
+				p = defaults.ApplyIfNil
+			case "never":
+				p = defaults.ApplyNever
+			default:
+				return "", opts, fmt.Errorf("invalid tag option policy=%s", arg)
+			}
+			if !seen[name] {
+				opts.policy = p
+			}
+		case name == "quantity" && acceptsQuantity(t):
+			q := true
+			if hasArg {
+				var err error
+				if q, err = strconv.ParseBool(arg); err != nil {
+					return "", opts, fmt.Errorf("invalid tag option quantity=%s", arg)
+				}
+			}
+			if !seen[name] {
+				opts.quantity = q
+			}
+		case name == "syntax" && hasArg && acceptsSyntax(t):
+			var sx defaults.Syntax
+			switch arg {
+			case "json":
+				sx = defaults.SyntaxJSON
+			case "short":
+				sx = defaults.SyntaxShort
+			default:
+				return "", opts, fmt.Errorf("invalid tag option syntax=%s", arg)
+			}
+			if !seen[name] {
+				opts.syntax = sx
+			}
+		default:
+			return tag, opts, nil
+		}
+		seen[name] = true
+		tag = tag[:i]
+	}
+}
+
+// nestedStruct reports whether t is a struct or struct pointer that Defaults
+// recurses into, returning the struct type and whether t is a pointer. Structs
+// with their own UnmarshalText or UnmarshalJSON method are parsed instead if tagged.
+func nestedStruct(t types.Type, tagged bool) (types.Type, bool, bool) {
+	elem, ptr := t, false
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		elem, ptr = p.Elem(), true
+	}
+	if _, ok := elem.Underlying().(*types.Struct); !ok || isTimeType(elem, "Location") || isTimeType(elem, "Time") {
+		return nil, false, false
+	}
+	if tagged && hasUnmarshaler(t, elem) {
+		return nil, false, false
+	}
+	return elem, ptr, true
+}
+
+// structElems reports whether t is a slice, array or map whose elements are structs
+// or struct pointers that Defaults recurses into, returning the struct type and
+// whether the elements are pointers.
+func structElems(t types.Type, tagged bool) (types.Type, bool, bool) {
+	var elem types.Type
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		elem = u.Elem()
+	case *types.Array:
+		elem = u.Elem()
+	case *types.Map:
+		elem = u.Elem()
+	default:
+		return nil, false, false
+	}
+	if tagged && hasUnmarshaler(t) {
+		return nil, false, false
+	}
+	return nestedStruct(elem, tagged)
+}
+
+// hasUnmarshaler reports whether any of ts has an UnmarshalText or UnmarshalJSON method.
+func hasUnmarshaler(ts ...types.Type) bool {
+	for _, t := range ts {
+		if typeconv.HasMethod(t, "UnmarshalText") || typeconv.HasMethod(t, "UnmarshalJSON") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsQuantity reports whether t holds integers or floats, directly or as the
+// elements of pointers, Opt values, slices, arrays and maps.
+func acceptsQuantity(t types.Type) bool {
+	for {
+		if named, ok := types.Unalias(t).(*types.Named); ok && isOpt(named) {
+			t = named.TypeArgs().At(0)
+			continue
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Map:
+			t = u.Elem()
+		case *types.Basic:
+			return u.Info()&(types.IsInteger|types.IsFloat) != 0
+		default:
+			return false
+		}
+	}
+}
+
+// acceptsSyntax reports whether fields of type t are slices, arrays or maps, or
+// pointers or Opt values holding them, which the syntax tag option applies to. The
+// layout option is never recognized, as time.Time fields are not supported.
+func acceptsSyntax(t types.Type) bool {
+	for {
+		if named, ok := types.Unalias(t).(*types.Named); ok && isOpt(named) {
+			t = named.TypeArgs().At(0)
+			continue
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice, *types.Array, *types.Map:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// isNillable reports whether fields of type t can be nil.
+func isNillable(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice, *types.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
+
+func isOpt(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == typeconv.OptPath && named.Obj().Name() == "Opt"
+}
+
+func isTimeType(t types.Type, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "time" && named.Obj().Name() == name
+}
+
+// untypedKind returns the untyped constant type literals of basic type t have.
+func untypedKind(t *types.Basic) types.Type {
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		return types.Typ[types.UntypedBool]
+	case t.Info()&types.IsString != 0:
+		return types.Typ[types.UntypedString]
+	case t.Info()&types.IsInteger != 0:
+		return types.Typ[types.UntypedInt]
+	case t.Info()&types.IsFloat != 0:
+		return types.Typ[types.UntypedFloat]
+	case t.Info()&types.IsComplex != 0:
+		return types.Typ[types.UntypedComplex]
+	}
+	return types.Typ[types.Invalid]
+}
+
+// exportName returns name with its first letter in upper case.
+func exportName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
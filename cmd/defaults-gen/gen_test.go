@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	file, got, err := generate([]string{"generatedTest"}, []string{"../.."}, "default", "")
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run go generate in the repository root", file)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	_, src, err := generate([]string{"Config"}, []string{"./testdata/gen"}, "default", "")
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"func (x *Config) SetDefaults() error",
+		"x.Level = Level(3)",
+		"x.Ratio = float32(2.0)",
+		`x.Timeouts = map[string]time.Duration{"read": time.Duration(1000000000)}`,
+		"x.Matrix = [][]int{[]int{1}, []int{2, 3}}",
+		"x.Servers = []Inner{Inner{Port: uint16(1)}}",
+		"for i := range x.Servers {\n\t\tif setDefaultsConfigInner(&x.Servers[i]) {",
+		"for _, v := range x.Pools {\n\t\tif v != nil && setDefaultsConfigInner(v) {",
+		`x.Any = []any{1.0, "a", true, nil, map[string]any{"k": []any{2.0}}}`,
+		"if v := new(Inner); setDefaultsConfigInner(v) {",
+		"if x.Never != nil && setDefaultsConfigInner(x.Never) {",
+		"if setDefaultsConfigAnon(&x.Anon) {",
+		"if setDefaultsConfigVersion(&x.Version) {",
+		"\tx.Forced = 7\n\tchanged = true\n",
+		"\t{\n\t\tv := 1\n\t\tx.ForcedA = &v\n\t\tchanged = true\n\t}\n",
+		"\t{\n\t\tv := \"x\"\n\t\tx.ForcedB = &v\n\t\tchanged = true\n\t}\n",
+		"if x.NilOnly == nil {\n\t\tx.NilOnly = []int{1}",
+		"x.Waits = []time.Duration{time.Duration(1000000000), time.Duration(5000000000)}",
+		"x.Month = time.Month(3)",
+		"x.Days = []time.Weekday{time.Weekday(6), time.Weekday(0)}",
+		"x.Buffer = int64(65536)",
+		`x.Label = "hi,quantity"`,
+		`x.Note = "a,syntax=short"`,
+		"x.MaxBody = defaults.ByteSize(1000000)",
+		"x.Keep = time.Duration(604800000000000)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		errString string
+	}{
+		{
+			name:      "recursive type",
+			input:     "Recursive",
+			errString: "Recursive.Next: recursive type Recursive is not supported",
+		},
+		{
+			name:      "text unmarshaler",
+			input:     "TextField",
+			errString: "TextField.Addr: type netip.Addr is not supported by defaults-gen",
+		},
+		{
+			name:      "JSON struct default",
+			input:     "StructJSON",
+			errString: "StructJSON.Inner: JSON defaults on struct fields are not supported",
+		},
+		{
+			name:      "overflow",
+			input:     "Overflow",
+			errString: "Overflow.Small: strconv.ParseInt: parsing \"300\": value out of range",
+		},
+		{
+			name:      "invalid option",
+			input:     "BadOption",
+			errString: "BadOption.Inner: invalid tag option alloc=sometimes",
+		},
+		{
+			name:      "unsupported type",
+			input:     "Unsupported",
+			errString: `Unsupported.Events: unsupported type "chan"`,
+		},
+		{
+			name:      "nested setter",
+			input:     "WithSetter",
+			errString: "WithSetter.Computed: type Computed has a SetDefaults method, which is not supported",
+		},
+		{
+			name:      "interpolation",
+			input:     "Interpolated",
+			errString: "Interpolated.Home: ${VAR} references are resolved at run time and not supported by defaults-gen",
+		},
+		{
+			name:      "provider",
+			input:     "Provided",
+			errString: "Provided.Host: providers are called at run time and not supported by defaults-gen",
+		},
+		{
+			name:      "time zone",
+			input:     "Zone",
+			errString: "Zone.Location: type time.Location is not supported by defaults-gen",
+		},
+		{
+			name:      "missing type",
+			input:     "Missing",
+			errString: "types Missing not found",
+		},
+		{
+			name:      "not a struct",
+			input:     "Level",
+			errString: "Level: not a struct type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := generate([]string{tt.input}, []string{"./testdata/gen"}, "default", "")
+			if err == nil || err.Error() != tt.errString {
+				t.Errorf("generate() error = %v, want %q", err, tt.errString)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Command defaults-gen generates reflection-free SetDefaults methods from default struct tags.
+//
+// Usage:
+//
+//	defaults-gen -type=T[,T...] [-tag=default] [-output=file] [package]
+//
+// It is meant to be run by go generate from the package declaring the types:
+//
+//	//go:generate go run github.com/lthphuw/go-defaults/cmd/defaults-gen -type=Config
+//
+// For each type T it generates
+//
+//	func (x *T) SetDefaults() error
+//	func (*T) DefaultsTag() string
+//
+// in t_defaults.go, or t_defaults_test.go if T is declared in a test file. The
+// methods implement defaults.Generated, so defaults.Defaults calls SetDefaults
+// instead of walking T with reflection.
+//
+// SetDefaults behaves like defaults.Defaults with its default settings: unset fields
+// receive their default unless a policy tag option says otherwise, nil struct pointers
+// are allocated following the alloc tag options, and nested structs are processed recursively. Tag values are parsed by
+// defaults-gen with the parsers of the defaults package and emitted as Go literals,
+// so invalid tags are reported when generating.
+//
+// Fields whose defaults depend on parsers or values only known at run time, such as
+// types implementing encoding.TextUnmarshaler or json.Unmarshaler such as time.Time,
+// *time.Location, JSON defaults on struct fields, ${VAR} references and @provider calls, as well as recursive struct
+// types and structs with their own SetDefaults method (see defaults.Setter) are not
+// supported; use defaults.Defaults for them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
+	output    = flag.String("output", "", "output file name; default <dir>/<type>_defaults.go")
+	tagKey    = flag.String("tag", "default", "struct tag key holding default values")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of defaults-gen:\n")
+	fmt.Fprintf(os.Stderr, "\tdefaults-gen -type=T[,T...] [-tag=default] [-output=file] [package]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("defaults-gen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	file, src, err := generate(strings.Split(*typeNames, ","), patterns, *tagKey, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(file, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
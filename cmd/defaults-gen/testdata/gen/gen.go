@@ -0,0 +1,98 @@
+package gen
+
+import (
+	"net/netip"
+	"time"
+
+	defaults "github.com/lthphuw/go-defaults"
+)
+
+type Level int8
+
+type Inner struct {
+	Port uint16 `default:"8080"`
+}
+
+// Version is parsed by UnmarshalText if tagged and recursed into otherwise.
+type Version struct {
+	Major int `default:"1"`
+}
+
+func (v *Version) UnmarshalText(text []byte) error { return nil }
+
+type Config struct {
+	Name     string                   `default:"app"`
+	Level    Level                    `default:"3"`
+	Ratio    float32                  `default:"2"`
+	Timeouts map[string]time.Duration `default:"{\"read\":1000000000}"`
+	Matrix   [][]int                  `default:"[[1],[2,3]]"`
+	Servers  []Inner                  `default:"[{\"Port\":1}]"`
+	Any      []any                    `default:"[1,\"a\",true,null,{\"k\":[2]}]"`
+	Lazy     *Inner                   `default:",alloc=defaulted"`
+	Never    *Inner                   `default:",noalloc"`
+	Pools    map[string]*Inner
+	Forced   int               `default:"7,policy=always"`
+	Skipped  int               `default:"7,policy=never"`
+	ForcedA  *int              `default:"1,policy=always"`
+	ForcedB  *string           `default:"x,policy=always"`
+	NilOnly  []int             `default:"[1],policy=ifnil"`
+	Waits    []time.Duration   `default:"1s,5s,syntax=short"`
+	Month    time.Month        `default:"March"`
+	Days     []time.Weekday    `default:"sat,sun,syntax=short"`
+	Buffer   int64             `default:"64KiB,quantity"`
+	Label    string            `default:"hi,quantity"`
+	Note     string            `default:"a,syntax=short"`
+	MaxBody  defaults.ByteSize `default:"1MB"`
+	Keep     time.Duration     `default:"7d"`
+	Version  Version
+	Started  time.Time
+	Anon     struct {
+		Retries int `default:"3"`
+	}
+}
+
+type Recursive struct {
+	Next *Recursive
+}
+
+type TextField struct {
+	Addr netip.Addr `default:"10.0.0.1"`
+}
+
+type StructJSON struct {
+	Inner Inner `default:"{\"Port\":1}"`
+}
+
+type Overflow struct {
+	Small int8 `default:"300"`
+}
+
+type BadOption struct {
+	Inner *Inner `default:",alloc=sometimes"`
+}
+
+type Unsupported struct {
+	Events chan int `default:"1"`
+}
+
+type Computed struct {
+	Workers int
+}
+
+func (c *Computed) SetDefaults() {}
+
+type WithSetter struct {
+	Computed Computed
+}
+
+type Interpolated struct {
+	Home string `default:"${HOME}/data"`
+}
+
+type Zone struct {
+	Location *time.Location `default:"UTC"`
+}
+
+type Provided struct {
+	Host string `default:"@hostname"`
+}
@@ -0,0 +1,20 @@
+// Command defaultsvet checks default struct tags at build time.
+//
+// It is meant to be run by go vet:
+//
+//	go vet -vettool=$(which defaultsvet) ./...
+//
+// The analyzer flags are passed to go vet with the analyzer name as prefix:
+//
+//	go vet -vettool=$(which defaultsvet) -defaultsvet.tag=env -defaultsvet.skip=net.IP ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/lthphuw/go-defaults/defaultsvet"
+)
+
+func main() {
+	unitchecker.Main(defaultsvet.Analyzer)
+}
@@ -0,0 +1,34 @@
+package defaults
+
+import "errors"
+
+// ErrCycle is returned when a nil struct pointer refers back to a struct type that is
+// already on the current path and the cycle policy action is CycleError.
+var ErrCycle = errors.New("cycle detected")
+
+// CycleAction selects what Defaults does when it reaches a nil struct pointer whose
+// type already occurs on the current path more often than the policy allows.
+type CycleAction int
+
+const (
+	// CycleStop leaves the recurring struct pointer nil.
+	CycleStop CycleAction = iota
+	// CycleError aborts with an error wrapping ErrCycle.
+	CycleError
+)
+
+// CyclePolicy controls how self-referential struct pointers such as
+// `type Node struct { Next *Node }` are handled.
+//
+// Nil struct pointers are only allocated while their type occurs on the current path
+// at most MaxDepth times; beyond that Action applies. The zero value stops at the first repeat.
+type CyclePolicy struct {
+	Action   CycleAction
+	MaxDepth int
+}
+
+// SetCyclePolicy sets the policy used by Defaults for self-referential struct pointers.
+// Use WithCyclePolicy to configure a Defaulter instead.
+func SetCyclePolicy(p CyclePolicy) {
+	std.cycle = p
+}
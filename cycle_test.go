@@ -0,0 +1,109 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Self-referential struct for testing cycle detection
+type testNode struct {
+	Value int `default:"1"`
+	Next  *testNode
+}
+
+// Mutually recursive structs for testing cycle detection
+type testTree struct {
+	Name string `default:"tree"`
+	Leaf *testLeaf
+}
+
+type testLeaf struct {
+	Weight int `default:"2"`
+	Tree   *testTree
+}
+
+func TestCyclePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    CyclePolicy
+		input     any
+		want      any
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:   "stop at first repeat",
+			policy: CyclePolicy{},
+			input:  &testNode{},
+			want:   &testNode{Value: 1},
+		},
+		{
+			name:   "allow two levels",
+			policy: CyclePolicy{MaxDepth: 2},
+			input:  &testNode{},
+			want:   &testNode{Value: 1, Next: &testNode{Value: 1, Next: &testNode{Value: 1}}},
+		},
+		{
+			name:   "mutual recursion",
+			policy: CyclePolicy{},
+			input:  &testTree{},
+			want:   &testTree{Name: "tree", Leaf: &testLeaf{Weight: 2}},
+		},
+		{
+			name:   "existing pointers are still followed",
+			policy: CyclePolicy{},
+			input:  &testNode{Next: &testNode{Next: &testNode{Value: 5}}},
+			want:   &testNode{Value: 1, Next: &testNode{Value: 1, Next: &testNode{Value: 5}}},
+		},
+		{
+			name:      "error on cycle",
+			policy:    CyclePolicy{Action: CycleError, MaxDepth: 1},
+			input:     &testNode{},
+			want:      &testNode{Value: 1, Next: &testNode{Value: 1}},
+			wantErr:   true,
+			errString: "cycle detected: Next.Next refers back to defaults.testNode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetCyclePolicy(tt.policy)
+			t.Cleanup(func() { SetCyclePolicy(CyclePolicy{}) })
+
+			err := Defaults(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, ErrCycle) {
+					t.Errorf("SetDefaults() error = %v, want ErrCycle", err)
+				}
+				if !strings.Contains(err.Error(), tt.errString) {
+					t.Errorf("SetDefaults() error = %v, expected to contain %q", err, tt.errString)
+				}
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %v, want %v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+func TestCyclicData(t *testing.T) {
+	a := &testNode{}
+	b := &testNode{Next: a}
+	a.Next = b
+
+	if err := Defaults(a); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	if a.Value != 1 || b.Value != 1 {
+		t.Errorf("SetDefaults() values = %d, %d, want 1, 1", a.Value, b.Value)
+	}
+	if a.Next != b || b.Next != a {
+		t.Errorf("SetDefaults() changed the cyclic pointers")
+	}
+}
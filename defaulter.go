@@ -0,0 +1,269 @@
+package defaults
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Hook is called after a field received its default value. path is the dotted
+// path of the field from the root struct and value is the field itself.
+// A non-nil error aborts Apply.
+type Hook func(path string, field reflect.StructField, value reflect.Value) error
+
+// Defaulter sets default values for struct fields with its own tag name, parsers,
+// policies and hooks. A Defaulter is safe for concurrent use once created.
+//
+// The package-level functions such as Defaults use a shared Defaulter configured
+// through Tag, SetDefaultTag, SetApplyPolicy, SetCyclePolicy, SetAllocPolicy, SetEmptyMode,
+// SetErrorPolicy, SetLookup, SetInterpolation, SetSyntax, SetQuantitySyntax
+// and SetClock.
+type Defaulter struct {
+	tag       string // empty means the package-level Tag
+	parsers   map[reflect.Type]ParserFunc
+	policy    ApplyPolicy
+	cycle     CyclePolicy
+	alloc     AllocPolicy
+	empty     EmptyMode
+	errors    ErrorPolicy
+	hooks     []Hook
+	env       LookupFunc // nil means os.LookupEnv
+	noInterp  bool       // disables ${VAR} interpolation
+	providers map[string]ProviderFunc
+	syntax    Syntax
+	quantity  bool             // quantity syntax for integer and float fields
+	clock     func() time.Time // nil means time.Now
+
+	plans sync.Map // planKey -> *structPlan
+}
+
+// Option configures a Defaulter.
+type Option func(*Defaulter)
+
+// std is the Defaulter behind the package-level functions.
+var std = &Defaulter{}
+
+// New returns a Defaulter using the "default" tag key and the given options.
+func New(opts ...Option) *Defaulter {
+	d := &Defaulter{tag: defaultTag}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithTag sets the struct tag key used to read default values.
+func WithTag(tag string) Option {
+	return func(d *Defaulter) {
+		if tag != "" {
+			d.tag = tag
+		}
+	}
+}
+
+// WithParser registers fn as the parser for fields of exactly type t on this
+// Defaulter only. It takes precedence over parsers registered with RegisterParser.
+func WithParser(t reflect.Type, fn ParserFunc) Option {
+	return func(d *Defaulter) {
+		if t == nil || fn == nil {
+			return
+		}
+		parsers := make(map[reflect.Type]ParserFunc, len(d.parsers)+1)
+		for k, v := range d.parsers {
+			parsers[k] = v
+		}
+		parsers[t] = fn
+		d.parsers = parsers
+	}
+}
+
+// WithOverwrite makes Apply set every tagged field to its default,
+// even if the field already holds a value. It is a shorthand for
+// WithApplyPolicy(ApplyAlways), or WithApplyPolicy(ApplyIfZero) if overwrite is false.
+func WithOverwrite(overwrite bool) Option {
+	if overwrite {
+		return WithApplyPolicy(ApplyAlways)
+	}
+	return WithApplyPolicy(ApplyIfZero)
+}
+
+// WithApplyPolicy sets when Apply sets tagged fields.
+func WithApplyPolicy(p ApplyPolicy) Option {
+	return func(d *Defaulter) {
+		d.policy = p
+	}
+}
+
+// WithCyclePolicy sets the policy used for self-referential struct pointers.
+func WithCyclePolicy(p CyclePolicy) Option {
+	return func(d *Defaulter) {
+		d.cycle = p
+	}
+}
+
+// WithAllocPolicy sets the policy used for nil struct pointer fields.
+func WithAllocPolicy(p AllocPolicy) Option {
+	return func(d *Defaulter) {
+		d.alloc = p
+	}
+}
+
+// WithEmptyMode sets when map and slice fields are treated as unset.
+func WithEmptyMode(m EmptyMode) Option {
+	return func(d *Defaulter) {
+		d.empty = m
+	}
+}
+
+// WithErrorPolicy sets whether Apply stops at the first failing field
+// or collects all failures.
+func WithErrorPolicy(p ErrorPolicy) Option {
+	return func(d *Defaulter) {
+		d.errors = p
+	}
+}
+
+// WithLookup sets the function resolving ${VAR} references in default tags,
+// such as MapLookup in tests. A nil fn restores os.LookupEnv.
+func WithLookup(fn LookupFunc) Option {
+	return func(d *Defaulter) {
+		d.env = fn
+	}
+}
+
+// WithInterpolation enables or disables the expansion of ${VAR} references
+// in default tags. It is enabled by default.
+func WithInterpolation(enabled bool) Option {
+	return func(d *Defaulter) {
+		d.noInterp = !enabled
+	}
+}
+
+// WithSyntax sets how Apply reads slice, array and map defaults.
+func WithSyntax(s Syntax) Option {
+	return func(d *Defaulter) {
+		d.syntax = s
+	}
+}
+
+// WithHook adds a hook called after each field received its default value.
+// Hooks run in the order they were added.
+func WithHook(h Hook) Option {
+	return func(d *Defaulter) {
+		if h != nil {
+			d.hooks = append(d.hooks[:len(d.hooks):len(d.hooks)], h)
+		}
+	}
+}
+
+// Apply sets default values for the fields of the struct s points to.
+// See Defaults for the rules applied, and Generated for types with generated code.
+func (d *Defaulter) Apply(s any) error {
+	v, err := structValue(s)
+	if err != nil {
+		return err
+	}
+	if g, ok := s.(Generated); ok && d.useGenerated(g) {
+		return g.SetDefaults()
+	}
+	return d.walk(v, d.policy)
+}
+
+// Reset is like the package-level Reset, using the Defaulter's settings
+// other than its apply policy.
+func (d *Defaulter) Reset(s any) error {
+	v, err := structValue(s)
+	if err != nil {
+		return err
+	}
+	return d.walk(v, ApplyAlways)
+}
+
+// structValue returns the struct s points to.
+func structValue(s any) (reflect.Value, error) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("%w: input must be a non-nil pointer to a struct", ErrInvalidInput)
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%w: input must be a pointer to a struct", ErrInvalidInput)
+	}
+	return v, nil
+}
+
+// walk sets the defaults of struct v, setting fields without a policy option under policy.
+func (d *Defaulter) walk(v reflect.Value, policy ApplyPolicy) error {
+	w := &walker{
+		d:        d,
+		tag:      d.tagKey(),
+		policy:   policy,
+		types:    make(map[reflect.Type]int),
+		visiting: map[visit]bool{{ptr: v.Addr().Pointer(), typ: v.Addr().Type()}: true},
+	}
+	_, err := w.setDefaults(v)
+	if err == nil {
+		err = w.resolveDeferred()
+	}
+	if !d.errors.Collect || (err == nil && len(w.errs) == 0) {
+		return err
+	}
+	return &MultiError{Errors: w.errs, Truncated: err == errTooMany}
+}
+
+// tagKey returns the struct tag key used by the Defaulter.
+func (d *Defaulter) tagKey() string {
+	if d.tag == "" {
+		return Tag
+	}
+	return d.tag
+}
+
+// lookup returns the parser for type t, preferring the Defaulter's own parsers.
+func (d *Defaulter) lookup(t reflect.Type) (ParserFunc, bool) {
+	if fn, ok := d.parsers[t]; ok {
+		return fn, true
+	}
+	return parsers.lookup(t)
+}
+
+// hasType reports whether a parser is registered for exactly type t.
+func (d *Defaulter) hasType(t reflect.Type) bool {
+	_, ok := d.parsers[t]
+	return ok || parsers.hasType(t)
+}
+
+// hasStructElems reports whether t is a slice, array or map whose elements are
+// structs or struct pointers to recurse into. tagged tells whether the field has a default.
+func (d *Defaulter) hasStructElems(t reflect.Type, tagged bool) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return isStructOrStructPtr(t.Elem()) && !d.parsedWhole(t.Elem(), tagged) && !d.parsedWhole(t, tagged)
+	default:
+		return false
+	}
+}
+
+// parsedWhole reports whether values of type t are parsed as a whole instead of
+// being recursed into: always if a parser is registered for t, and only for fields
+// with a default if t has its own UnmarshalText or UnmarshalJSON method.
+func (d *Defaulter) parsedWhole(t reflect.Type, tagged bool) bool {
+	if tagged {
+		return d.hasCustomParser(t)
+	}
+	return d.hasType(t) || t.Kind() == reflect.Ptr && d.hasType(t.Elem())
+}
+
+// hasCustomParser checks if the type or, for pointers, its element type is parsed
+// by a registered parser or by its own UnmarshalText or UnmarshalJSON method.
+func (d *Defaulter) hasCustomParser(t reflect.Type) bool {
+	if _, ok := d.parsers[t]; ok || parsers.hasCustom(t) {
+		return true
+	}
+	if t.Kind() != reflect.Ptr {
+		return false
+	}
+	_, ok := d.parsers[t.Elem()]
+	return ok || parsers.hasCustom(t.Elem())
+}
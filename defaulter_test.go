@@ -0,0 +1,231 @@
+package defaults
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Struct for testing Defaulter options
+type testDefaulter struct {
+	Port    int            `default:"8080" env:"9090"`
+	Host    string         `default:"localhost" env:"example.com"`
+	Celsius testCelsius    `default:"21.5C"`
+	Nested  *testServer    `env:"{\"Port\":1}"`
+	Tags    map[string]any `default:"{\"a\":1}"`
+}
+
+// Struct for testing concurrent Defaulters with different tags
+type testServerTags struct {
+	Port int `default:"8080" env:"9090"`
+}
+
+func TestDefaulterOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		input *testDefaulter
+		want  *testDefaulter
+	}{
+		{
+			name:  "custom tag",
+			opts:  []Option{WithTag("env"), WithParser(reflect.TypeFor[testCelsius](), ParseStruct)},
+			input: &testDefaulter{},
+			want: &testDefaulter{
+				Port:   9090,
+				Host:   "example.com",
+				Nested: &testServer{Port: 1},
+			},
+		},
+		{
+			name:  "instance parser",
+			opts:  []Option{WithParser(reflect.TypeFor[testCelsius](), reflectParser(parseCelsius))},
+			input: &testDefaulter{},
+			want: &testDefaulter{
+				Port:    8080,
+				Host:    "localhost",
+				Celsius: testCelsius{Degrees: 21.5},
+				Nested:  &testServer{Port: 8080},
+				Tags:    map[string]any{"a": 1.0},
+			},
+		},
+		{
+			name: "overwrite",
+			opts: []Option{
+				WithOverwrite(true),
+				WithParser(reflect.TypeFor[testCelsius](), reflectParser(parseCelsius)),
+			},
+			input: &testDefaulter{
+				Port:    1,
+				Host:    "set",
+				Celsius: testCelsius{Degrees: 1},
+				Nested:  &testServer{Port: 1},
+				Tags:    map[string]any{"b": 2},
+			},
+			want: &testDefaulter{
+				Port:    8080,
+				Host:    "localhost",
+				Celsius: testCelsius{Degrees: 21.5},
+				Nested:  &testServer{Port: 8080},
+				Tags:    map[string]any{"a": 1.0},
+			},
+		},
+		{
+			name: "alloc policy",
+			opts: []Option{
+				WithAllocPolicy(AllocNever),
+				WithParser(reflect.TypeFor[testCelsius](), reflectParser(parseCelsius)),
+			},
+			input: &testDefaulter{},
+			want: &testDefaulter{
+				Port:    8080,
+				Host:    "localhost",
+				Celsius: testCelsius{Degrees: 21.5},
+				Tags:    map[string]any{"a": 1.0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New(tt.opts...).Apply(tt.input); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("Apply() input = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaulterIsolation(t *testing.T) {
+	d := New(WithParser(reflect.TypeFor[testCelsius](), reflectParser(parseCelsius)))
+
+	// The instance parser must not leak into the package-level Defaults
+	err := Defaults(&struct {
+		Celsius testCelsius `default:"21.5C"`
+	}{})
+	if err == nil {
+		t.Errorf("Defaults() error = nil, want error for the unregistered parser")
+	}
+
+	// Instances with different tags can be used concurrently
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tag, want := "default", 8080
+			if i%2 == 0 {
+				tag, want = "env", 9090
+			}
+			var got testServerTags
+			if err := New(WithTag(tag)).Apply(&got); err != nil {
+				t.Errorf("Apply() error = %v", err)
+			}
+			if got.Port != want {
+				t.Errorf("Apply() with tag %s Port = %d, want %d", tag, got.Port, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got testDefaulter
+	if err := d.Apply(&got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.Celsius.Degrees != 21.5 {
+		t.Errorf("Apply() Celsius = %v, want 21.5", got.Celsius.Degrees)
+	}
+}
+
+func TestDefaulterCyclePolicy(t *testing.T) {
+	err := New(WithCyclePolicy(CyclePolicy{Action: CycleError})).Apply(&testNode{})
+	if !errors.Is(err, ErrCycle) {
+		t.Errorf("Apply() error = %v, want ErrCycle", err)
+	}
+}
+
+func TestDefaulterHooks(t *testing.T) {
+	var calls []string
+	record := func(path string, field reflect.StructField, value reflect.Value) error {
+		calls = append(calls, fmt.Sprintf("%s=%v", path, value.Interface()))
+		return nil
+	}
+	input := &testStructJSON{Inner: testInner{A: 5}}
+	if err := New(WithHook(record)).Apply(input); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []string{
+		"Inner.B=default b",
+		"Inner.C=nested",
+		"InnerPtr=&{2  }",
+		"InnerPtr.B=default b",
+		"InnerPtr.C=nested",
+		"Pair={l r}",
+		"PairPtr=&{p q}",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hook calls = %q, want %q", calls, want)
+	}
+
+	failing := func(path string, field reflect.StructField, value reflect.Value) error {
+		if path == "InnerPtr.B" {
+			return errors.New("rejected")
+		}
+		return nil
+	}
+	err := New(WithHook(failing)).Apply(&testStructJSON{})
+	if err == nil || !strings.Contains(err.Error(), "failed to set default for field InnerPtr.B: hook failed: rejected") {
+		t.Errorf("Apply() error = %v, expected to contain %q", err, "failed to set default for field InnerPtr.B: hook failed: rejected")
+	}
+}
+
+func TestDefaulterHookElementPaths(t *testing.T) {
+	var paths []string
+	record := func(path string, field reflect.StructField, value reflect.Value) error {
+		paths = append(paths, path)
+		return nil
+	}
+	input := &testCollections{
+		Slice:  []testInner{{A: 1, B: "b", C: "c"}, {A: 1, B: "b"}},
+		Map:    map[string]testInner{"k": {A: 1, C: "c"}},
+		MapPtr: map[int]*testInner{2: {B: "b", C: "c"}},
+	}
+	if err := New(WithHook(record)).Apply(input); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []string{
+		"Slice[1].C",
+		"Array[0].A", "Array[0].B", "Array[0].C",
+		"Array[1].A", "Array[1].B", "Array[1].C",
+		"Map[k].B",
+		"MapPtr[2].A",
+		"Default",
+		"Default[0].B", "Default[0].C",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("hook paths = %q, want %q", paths, want)
+	}
+}
+
+func TestDefaulterInvalidInput(t *testing.T) {
+	err := New().Apply(testDefaulter{})
+	if err == nil || !strings.Contains(err.Error(), "input must be a non-nil pointer to a struct") {
+		t.Errorf("Apply() error = %v, expected to contain %q", err, "input must be a non-nil pointer to a struct")
+	}
+}
+
+// reflectParser adapts a typed parser to a ParserFunc.
+func reflectParser[T any](fn func(string) (T, error)) ParserFunc {
+	return func(str string, _ reflect.Type) (reflect.Value, error) {
+		val, err := fn(str)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(val), nil
+	}
+}
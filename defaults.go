@@ -1,147 +1,434 @@
 package defaults
 
 import (
+	"cmp"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // Defaults sets default values for struct fields based on their "default" tags.
 //
 // It takes a pointer to a struct as input and processes each exported field with a "default" tag.
-// If a field is unset (zero value for non-pointers or nil for pointers), the function parses the tag value
-// and sets it according to the field's type. Supported types include int, int8, int16, int32, int64,
-// uint, uint8, uint16, uint32, uint64, float32, float64, complex64, complex128, bool, string,
-// time.Duration, map, slice, array, and nested structs (including pointers to these types).
-// The function recursively processes nested structs. It skips unexported fields, non-zero fields,
-// and fields without a "default" tag unless they are structs or struct pointers.
+// If a field is unset, the function parses the tag value and sets it according to the field's type.
+// A pointer is unset when nil; other fields are unset when not set according to their Presence
+// (as for Opt), when their own IsZero() bool method reports true (as for time.Time), or else when they
+// hold their zero value. SetEmptyMode decides whether empty non-nil maps and slices are unset too.
+// Supported types include int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+// float32, float64, complex64, complex128, bool, string, time.Duration, map, slice, array, and
+// nested structs (including pointers to these types); see the package documentation for the full list.
+// The function recursively processes nested structs, as well as the struct and non-nil struct pointer
+// elements of slices, arrays and map values. It skips unexported fields, fields that are set, and
+// fields without a "default" tag unless they are structs or struct pointers, or collections of them.
 //
 // Errors are returned for invalid inputs, unsupported types, or parsing failures.
+// By default Defaults stops at the first failing field; see SetErrorPolicy to collect all of them.
+//
+// Defaults uses a shared Defaulter configured by the package-level Tag, SetApplyPolicy, SetCyclePolicy,
+// SetAllocPolicy, SetEmptyMode and SetErrorPolicy. Use New to create an independently configured Defaulter.
 func Defaults(s any) error {
-	v := reflect.ValueOf(s)
-	if v.Kind() != reflect.Ptr || v.IsNil() {
-		return fmt.Errorf("input must be a non-nil pointer to a struct")
-	}
-	v = v.Elem()
-	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("input must be a pointer to a struct")
-	}
+	return std.Apply(s)
+}
+
+// walker carries the state of a single Apply call.
+type walker struct {
+	d        *Defaulter
+	tag      string               // struct tag key
+	policy   ApplyPolicy          // policy for fields without a policy option
+	path     []string             // field names from the root to the current struct
+	types    map[reflect.Type]int // occurrences of each struct type on the current path
+	visiting map[visit]bool       // struct pointers on the current path
+	scope    []reflect.Value      // structs from the root to the current one
+	errs     []error              // failures collected under ErrorPolicy.Collect
+
+	// Fields with ${.Field} references, set after the walk (see refs.go)
+	deferred   []*deferred
+	deferredAt map[visit]*deferred // by field address
+	active     []*deferred         // deferred fields being resolved
+	fixups     []func()            // store back map values holding deferred fields
+}
 
-	return setDefaults(v)
+// visit identifies a struct pointer; the type disambiguates zero-sized values sharing an address.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
 }
 
-// setDefaults recursively sets default values for a struct's fields.
-func setDefaults(v reflect.Value) error {
+// setDefaults recursively sets default values for a struct's fields following its compiled plan.
+// It reports whether any field, including nested ones, received a default.
+func (w *walker) setDefaults(v reflect.Value) (bool, error) {
 	t := v.Type()
-	for i := range v.NumField() {
-		field := t.Field(i)
-		fieldVal := v.Field(i)
+	w.types[t]++
+	w.scope = append(w.scope, v)
+	defer func() {
+		w.types[t]--
+		w.scope = w.scope[:len(w.scope)-1]
+	}()
 
-		// Skip unexported or unsettable fields
-		if !assignable(field, fieldVal) {
+	changed := false
+	p := w.d.plan(t, w.tag)
+	for _, fp := range p.fields {
+		fieldVal := v.Field(fp.index)
+
+		// Skip unsettable fields
+		if !fieldVal.CanSet() {
 			continue
 		}
 
-		// Handle nested structs or struct pointers
-		if isStructOrStructPtr(fieldVal) &&
-			(field.Tag.Get(Tag) == "" || fieldVal.Kind() == reflect.Struct) {
-			if fieldVal.Kind() == reflect.Ptr {
-				if fieldVal.IsNil() {
-					// Initialize nil struct pointer
-					fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
-				}
-				// Recurse into the struct
-				if err := setDefaults(fieldVal.Elem()); err != nil {
-					return fmt.Errorf("failed to set defaults for field %s: %w", field.Name, err)
-				}
-			} else {
-				// Recurse into the struct
-				if err := setDefaults(fieldVal); err != nil {
-					return fmt.Errorf("failed to set defaults for field %s: %w", field.Name, err)
-				}
+		// Report invalid tag options
+		if fp.tagErr != nil {
+			if err := w.fail(w.fieldError(t, fp, fp.tagErr)); err != nil {
+				return changed, err
 			}
 			continue
 		}
 
-		// Skip if field is not unset (non-zero for non-pointers or non-nil for pointers)
-		if !isUnset(fieldVal) {
+		// Handle nested structs or struct pointers, unless they have a parser of their own
+		if fp.nested {
+			// Decode a JSON object default into the struct before recursing,
+			// so nested tags fill the fields the literal left unset
+			literal := false
+			if fp.value != "" && w.shouldSet(fp, fieldVal) {
+				if err := w.fail(w.setField(t, fp, fieldVal)); err != nil {
+					return changed, err
+				}
+				changed, literal = true, true
+			}
+			restore := w.fillUnset(literal)
+			nestedChanged, err := w.setNestedDefaults(t, fp, fieldVal)
+			restore()
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || nestedChanged
 			continue
 		}
 
-		// Get the default tag
-		tagVal := field.Tag.Get(Tag)
-		if tagVal == "" {
-			continue
+		// Set the default value, unless the field has none or its policy keeps the current value.
+		// Values referring to other fields are set once the whole struct tree was defaulted.
+		literal := false
+		if fp.value != "" && w.shouldSet(fp, fieldVal) {
+			if fp.fieldRefs && !w.d.noInterp {
+				w.deferField(t, fp, fieldVal)
+			} else if err := w.fail(w.setField(t, fp, fieldVal)); err != nil {
+				return changed, err
+			} else {
+				literal = true
+			}
+			changed = true
+		}
+
+		// Handle struct elements of slices, arrays and maps, including those just set
+		if fp.elems {
+			restore := w.fillUnset(literal)
+			elemsChanged, err := w.setElemDefaults(fp, fieldVal)
+			restore()
+			if err != nil {
+				return changed, err
+			}
+			changed = changed || elemsChanged
 		}
+	}
 
-		// Parse and set the default value
-		if err := setFieldValue(fieldVal, field.Type, tagVal); err != nil {
-			return fmt.Errorf("failed to set default for field %s: %w", field.Name, err)
+	// Let the struct compute its own defaults, after those of its nested structs.
+	// A setter call counts as a default (see Setter).
+	if p.setter != setterNone {
+		changed = true
+	}
+	if err := callSetter(p.setter, v); err != nil {
+		err = &FieldError{
+			Path:   w.fieldPath(""),
+			Struct: t.String(),
+			Type:   t,
+			Err:    fmt.Errorf("SetDefaults failed: %w", err),
+		}
+		if err := w.fail(err); err != nil {
+			return changed, err
 		}
 	}
+	return changed, nil
+}
+
+// fail records err under ErrorPolicy.Collect and returns nil, or errTooMany if
+// MaxErrors failures were already recorded. Otherwise it returns err to stop the walk.
+func (w *walker) fail(err error) error {
+	if err == nil || !w.d.errors.Collect {
+		return err
+	}
+	if w.d.errors.MaxErrors > 0 && len(w.errs) >= w.d.errors.MaxErrors {
+		return errTooMany
+	}
+	w.errs = append(w.errs, err)
 	return nil
 }
 
-// assignable checks if a field is exported and can be set.
-func assignable(field reflect.StructField, fieldVal reflect.Value) bool {
-	return field.IsExported() && fieldVal.CanSet()
+// setField sets the default value of a field of struct type t and runs the hooks.
+func (w *walker) setField(t reflect.Type, fp *fieldPlan, fieldVal reflect.Value) error {
+	val, err := w.d.defaultValue(fp)
+	if err != nil {
+		return w.fieldError(t, fp, err)
+	}
+	return w.setValue(t, fp, fieldVal, val)
 }
 
-// isUnset checks if a field is unset (nil for pointers, zero value for non-pointers).
-func isUnset(val reflect.Value) bool {
-	if val.Kind() == reflect.Ptr {
-		return val.IsNil()
+// setValue sets a field of struct type t to val and runs the hooks.
+func (w *walker) setValue(t reflect.Type, fp *fieldPlan, fieldVal, val reflect.Value) error {
+	fieldVal.Set(val)
+	if len(w.d.hooks) == 0 {
+		return nil
 	}
-	return reflect.DeepEqual(val.Interface(), reflect.Zero(val.Type()).Interface())
+	path := w.fieldPath(fp.field.Name)
+	for _, hook := range w.d.hooks {
+		if err := hook(path, fp.field, fieldVal); err != nil {
+			return w.fieldError(t, fp, fmt.Errorf("hook failed: %w", err))
+		}
+	}
+	return nil
 }
 
-// isStructOrStructPtr checks if a value is a struct or a pointer to a struct.
-func isStructOrStructPtr(val reflect.Value) bool {
-	return val.Kind() == reflect.Struct ||
-		(val.Kind() == reflect.Ptr && val.Type().Elem().Kind() == reflect.Struct)
+// fieldPath returns the path of the named field of the current struct.
+func (w *walker) fieldPath(name string) string {
+	var b strings.Builder
+	for _, seg := range w.path {
+		if !strings.HasPrefix(seg, "[") && b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	if name != "" {
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(name)
+	}
+	return b.String()
 }
 
-// setFieldValue parses the tag value and sets it to the field based on its type.
-func setFieldValue(fieldVal reflect.Value, fieldType reflect.Type, tagVal string) error {
-	// Handle pointer types by initializing and setting the element
-	if fieldType.Kind() == reflect.Ptr {
-		if fieldVal.IsNil() {
-			fieldVal.Set(reflect.New(fieldType.Elem()))
+// fieldError wraps err in a FieldError for a field of struct type t.
+func (w *walker) fieldError(t reflect.Type, fp *fieldPlan, err error) error {
+	return &FieldError{
+		Path:   w.fieldPath(fp.field.Name),
+		Struct: t.String(),
+		Type:   fp.field.Type,
+		Tag:    fp.field.Tag.Get(w.tag),
+		Err:    err,
+	}
+}
+
+// setNestedDefaults recurses into a struct or struct pointer field. Nil struct pointers
+// are allocated according to the alloc policy, unless that would repeat a type on the
+// path more than the cycle policy allows.
+func (w *walker) setNestedDefaults(t reflect.Type, fp *fieldPlan, fieldVal reflect.Value) (bool, error) {
+	if fieldVal.Kind() != reflect.Ptr {
+		// Recurse into the struct
+		return w.setStruct(fp.field.Name, fieldVal)
+	}
+
+	if fieldVal.IsNil() {
+		alloc := w.d.alloc
+		if fp.opts.hasAlloc {
+			alloc = fp.opts.alloc
+		}
+		if alloc == AllocNever {
+			return false, nil
 		}
-		fieldVal = fieldVal.Elem()
-		fieldType = fieldType.Elem()
+
+		elemType := fieldVal.Type().Elem()
+		if w.types[elemType] > w.d.cycle.MaxDepth {
+			if w.d.cycle.Action == CycleError {
+				return false, w.fail(w.fieldError(t, fp, fmt.Errorf("%w: %s refers back to %s",
+					ErrCycle, w.fieldPath(fp.field.Name), elemType)))
+			}
+			return false, nil
+		}
+
+		// Initialize nil struct pointer, keeping it only if required by the policy
+		ptr := reflect.New(elemType)
+		changed, err := w.setStructPtr(fp.field.Name, ptr)
+		if changed || alloc == AllocAlways {
+			fieldVal.Set(ptr)
+		}
+		return changed, err
 	}
-	var typeName string
-	if fieldType.Name() == "" {
-		typeName = fieldType.Kind().String()
-	} else {
-		typeName = fieldType.String()
+
+	return w.setStructPtr(fp.field.Name, fieldVal)
+}
+
+// setElemDefaults recurses into the struct or non-nil struct pointer elements of a
+// slice, array or map field. Map values are not addressable, so struct values are
+// defaulted on a copy that is stored back if it changed.
+func (w *walker) setElemDefaults(fp *fieldPlan, fieldVal reflect.Value) (bool, error) {
+	w.path = append(w.path, fp.field.Name)
+	defer func() { w.path = w.path[:len(w.path)-1] }()
+
+	changed := false
+	if fieldVal.Kind() == reflect.Map {
+		for _, key := range sortedKeys(fieldVal) {
+			elem := fieldVal.MapIndex(key)
+			name := fmt.Sprintf("[%v]", key)
+			if elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					continue
+				}
+				elemChanged, err := w.setStructPtr(name, elem)
+				if err != nil {
+					return changed, err
+				}
+				changed = changed || elemChanged
+				continue
+			}
+			tmp := reflect.New(elem.Type()).Elem()
+			tmp.Set(elem)
+			n := len(w.deferred)
+			elemChanged, err := w.setStruct(name, tmp)
+			if elemChanged {
+				fieldVal.SetMapIndex(key, tmp)
+				changed = true
+			}
+			if len(w.deferred) > n {
+				// Deferred fields are set on tmp after the walk
+				w.fixups = append(w.fixups, func() { fieldVal.SetMapIndex(key, tmp) })
+			}
+			if err != nil {
+				return changed, err
+			}
+		}
+		return changed, nil
+	}
+
+	for i := range fieldVal.Len() {
+		elem := fieldVal.Index(i)
+		name := "[" + strconv.Itoa(i) + "]"
+		var elemChanged bool
+		var err error
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elemChanged, err = w.setStructPtr(name, elem)
+		} else {
+			elemChanged, err = w.setStruct(name, elem)
+		}
+		changed = changed || elemChanged
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// setStructPtr recurses into the struct a non-nil pointer points to,
+// skipping pointers already being processed, as in cyclic data.
+func (w *walker) setStructPtr(name string, ptr reflect.Value) (bool, error) {
+	key := visit{ptr: ptr.Pointer(), typ: ptr.Type()}
+	if w.visiting[key] {
+		return false, nil
+	}
+	w.visiting[key] = true
+	defer delete(w.visiting, key)
+
+	// Recurse into the struct
+	return w.setStruct(name, ptr.Elem())
+}
+
+// setStruct recurses into a nested struct, extending the path with name.
+func (w *walker) setStruct(name string, v reflect.Value) (bool, error) {
+	w.path = append(w.path, name)
+	defer func() { w.path = w.path[:len(w.path)-1] }()
+	return w.setDefaults(v)
+}
+
+// sortedKeys returns the keys of map m in a deterministic order: numerically for
+// numbers, lexically for strings, and by their formatted value otherwise.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(a.Int(), b.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(a.Uint(), b.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(a.Float(), b.Float())
+		case reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		default:
+			return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		}
+	})
+	return keys
+}
+
+// isStructOrStructPtr checks if a type is a struct or a pointer to a struct.
+func isStructOrStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct ||
+		(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct)
+}
+
+// parseValue parses the tag value into a value of type t.
+func (d *Defaulter) parseValue(t reflect.Type, tagVal string, vo valueOptions) (reflect.Value, error) {
+	// Handle pointer types by parsing the element,
+	// unless a parser is registered for the pointer type itself
+	if t.Kind() == reflect.Ptr && !d.hasType(t) {
+		elem, err := d.parseValue(t.Elem(), tagVal, vo)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	// Parse optional values into their wrapped type
+	if opt, ok := reflect.New(t).Interface().(optional); ok {
+		val, err := d.parseValue(opt.valueType(), tagVal, vo)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		opt.setValue(val)
+		return reflect.ValueOf(opt).Elem(), nil
+	}
+
+	if d.isShort(t, vo) {
+		return d.parseShort(t, tagVal, vo)
 	}
 
 	// Look up parser function
-	parserFunc, exists := parser[typeName]
+	parserFunc, exists := d.lookup(t)
 	if !exists {
-		return fmt.Errorf(`%s "%s"`, ErrUnsupportedType.Error(), typeName)
+		return reflect.Value{}, fmt.Errorf("%w %q", ErrUnsupportedType, typeName(t))
+	}
+	if t == timeType && sameFunc(parserFunc, ParseTime) {
+		// The built-in parser, with the layout option and the Defaulter's clock
+		return d.parseTime(tagVal, vo.layout)
+	}
+	if vo.quantity && isQuantityKind(t.Kind()) && !d.hasType(t) {
+		parserFunc = ParseQuantity
 	}
 
 	// Parse the value
-	parsedVal, err := parserFunc(tagVal, fieldType)
+	parsedVal, err := parserFunc(tagVal, t)
 	if err != nil {
-		return err
+		return reflect.Value{}, err
 	}
-
-	// Set the parsed value
-	if parsedVal.IsValid() {
-		if fieldVal.Kind() == reflect.Map || fieldVal.Kind() == reflect.Slice ||
-			fieldVal.Kind() == reflect.Array {
-			if parsedVal.Type().ConvertibleTo(fieldType) {
-				fieldVal.Set(parsedVal)
-			} else {
-				return fmt.Errorf("parsed value type %v cannot be converted to field type %v", parsedVal.Type(), fieldType)
-			}
-		} else {
-			fieldVal.Set(parsedVal)
-		}
+	if !parsedVal.IsValid() {
+		return reflect.Zero(t), nil
+	}
+	if parsedVal.Type().AssignableTo(t) {
+		return parsedVal, nil
+	}
+	if parsedVal.Type().ConvertibleTo(t) {
+		return parsedVal.Convert(t), nil
 	}
+	return reflect.Value{}, fmt.Errorf("parsed value type %v cannot be converted to field type %v", parsedVal.Type(), t)
+}
 
-	return nil
+// typeName returns the name used for a type in error messages.
+func typeName(t reflect.Type) string {
+	if t.Name() == "" {
+		return t.Kind().String()
+	}
+	return t.String()
 }
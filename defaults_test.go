@@ -1,10 +1,16 @@
 package defaults
 
 import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/netip"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/segmentio/encoding/json"
 )
 
 func TestSetDefaults(t *testing.T) {
@@ -730,6 +736,253 @@ func TestFuncTypeCases(t *testing.T) {
 	}
 }
 
+// TestNamedTypes tests named types whose underlying kind is supported
+func TestNamedTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		want      any
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "named basic, collection and pointer types",
+			input: &testNamed{},
+			want: &testNamed{
+				Port:     8080,
+				Level:    "debug",
+				Ratio:    0.5,
+				Enabled:  true,
+				Tags:     testTags{"a", "b"},
+				Labels:   testLabels{"env": "prod"},
+				Triple:   testTriple{1, 2, 3},
+				Timeout:  2 * time.Second,
+				PortPtr:  portPtr(9090),
+				LevelPtr: levelPtr("info"),
+			},
+		},
+		{
+			name:      "named int overflow",
+			input:     &testNamedOverflow{},
+			want:      &testNamedOverflow{},
+			wantErr:   true,
+			errString: "value out of range",
+		},
+		{
+			name:      "named func type",
+			input:     &testNamedFunc{},
+			want:      &testNamedFunc{},
+			wantErr:   true,
+			errString: `unsupported type "defaults.testHandler"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errString) {
+				t.Errorf("SetDefaults() error = %v, expected to contain %q", err, tt.errString)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %v, want %v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+// TestTextUnmarshalerTypes tests types implementing encoding.TextUnmarshaler
+func TestTextUnmarshalerTypes(t *testing.T) {
+	var got testTextUnmarshaler
+	if err := Defaults(&got); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	want := testTextUnmarshaler{
+		Addr:    netip.MustParseAddr("10.0.0.1"),
+		AddrPtr: func() *netip.Addr { a := netip.MustParseAddr("::1"); return &a }(),
+		Big:     big.NewInt(0).Lsh(big.NewInt(1), 100),
+		Level:   slog.LevelWarn,
+		Color:   testColorBlue,
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SetDefaults() input = %v, want %v", got, want)
+	}
+
+	err := Defaults(&testTextUnmarshalerError{})
+	if err == nil || !strings.Contains(err.Error(), `unknown color "purple"`) {
+		t.Errorf("SetDefaults() error = %v, expected to contain %q", err, `unknown color "purple"`)
+	}
+}
+
+// TestStructJSONDefaults tests JSON object defaults on struct fields and json.Unmarshaler types
+func TestStructJSONDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		want      any
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "struct literal with nested defaults",
+			input: &testStructJSON{},
+			want: &testStructJSON{
+				Inner:    testInner{A: 1, B: "x", C: "nested"},
+				InnerPtr: &testInner{A: 2, B: "default b", C: "nested"},
+				Pair:     testPair{Left: "l", Right: "r"},
+				PairPtr:  &testPair{Left: "p", Right: "q"},
+			},
+		},
+		{
+			name: "already set struct",
+			input: &testStructJSON{
+				Inner:    testInner{A: 5},
+				InnerPtr: &testInner{B: "set"},
+				Pair:     testPair{Left: "a"},
+			},
+			want: &testStructJSON{
+				Inner:    testInner{A: 5, B: "default b", C: "nested"},
+				InnerPtr: &testInner{A: 10, B: "set", C: "nested"},
+				Pair:     testPair{Left: "a"},
+				PairPtr:  &testPair{Left: "p", Right: "q"},
+			},
+		},
+		{
+			name:  "untagged structs with unmarshalers",
+			input: &testUntaggedUnmarshalers{Versions: []testRelease{{Major: 7}}},
+			want: &testUntaggedUnmarshalers{
+				Pair:     testPair{Right: "ignored"},
+				PairPtr:  &testPair{Right: "ignored"},
+				Version:  testRelease{Major: 1, Minor: 2},
+				Versions: []testRelease{{Major: 7, Minor: 2}},
+				Tagged:   testRelease{Major: 3, Minor: 4},
+			},
+		},
+		{
+			name:      "invalid struct literal",
+			input:     &testStructJSONError{},
+			want:      &testStructJSONError{},
+			wantErr:   true,
+			errString: "invalid struct format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errString) {
+				t.Errorf("SetDefaults() error = %v, expected to contain %q", err, tt.errString)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %v, want %v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectionElements(t *testing.T) {
+	inner := testInner{A: 10, B: "default b", C: "nested"}
+	tests := []struct {
+		name      string
+		input     any
+		want      any
+		wantErr   bool
+		errString string
+	}{
+		{
+			name: "slice of structs",
+			input: &testCollections{
+				Slice: []testInner{{}, {A: 1}},
+			},
+			want: &testCollections{
+				Slice:   []testInner{{A: 10, B: "default b", C: "nested"}, {A: 1, B: "default b", C: "nested"}},
+				Array:   [2]testInner{inner, inner},
+				Default: []testInner{{A: 3, B: "default b", C: "nested"}},
+			},
+		},
+		{
+			name: "slice of struct pointers with nil",
+			input: &testCollections{
+				Ptrs: []*testInner{nil, {B: "set"}},
+			},
+			want: &testCollections{
+				Ptrs:    []*testInner{nil, {A: 10, B: "set", C: "nested"}},
+				Array:   [2]testInner{inner, inner},
+				Default: []testInner{{A: 3, B: "default b", C: "nested"}},
+			},
+		},
+		{
+			name:  "array of structs",
+			input: &testCollections{Array: [2]testInner{{C: "set"}}},
+			want: &testCollections{
+				Array:   [2]testInner{{A: 10, B: "default b", C: "set"}, inner},
+				Default: []testInner{{A: 3, B: "default b", C: "nested"}},
+			},
+		},
+		{
+			name: "maps of structs and struct pointers",
+			input: &testCollections{
+				Map:    map[string]testInner{"a": {}, "b": {A: 1}},
+				MapPtr: map[int]*testInner{1: {}, 2: nil},
+			},
+			want: &testCollections{
+				Map: map[string]testInner{
+					"a": {A: 10, B: "default b", C: "nested"},
+					"b": {A: 1, B: "default b", C: "nested"},
+				},
+				MapPtr:  map[int]*testInner{1: {A: 10, B: "default b", C: "nested"}, 2: nil},
+				Array:   [2]testInner{inner, inner},
+				Default: []testInner{{A: 3, B: "default b", C: "nested"}},
+			},
+		},
+		{
+			name: "set default is kept",
+			input: &testCollections{
+				Default: []testInner{{A: 5}},
+			},
+			want: &testCollections{
+				Array:   [2]testInner{inner, inner},
+				Default: []testInner{{A: 5, B: "default b", C: "nested"}},
+			},
+		},
+		{
+			name: "element error",
+			input: &testCollectionError{
+				Items: map[string]testCollectionItem{"x": {}},
+			},
+			want: &testCollectionError{
+				Items: map[string]testCollectionItem{"x": {}},
+			},
+			wantErr:   true,
+			errString: "failed to set default for field Items[x].Port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errString) {
+				t.Errorf("SetDefaults() error = %v, expected to contain %q", err, tt.errString)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
 func intPtr(x int) *int {
 	return &x
 }
@@ -814,6 +1067,14 @@ func durationPtr(x time.Duration) *time.Duration {
 	return &x
 }
 
+func portPtr(x testPort) *testPort {
+	return &x
+}
+
+func levelPtr(x testLevel) *testLevel {
+	return &x
+}
+
 type testStruct struct {
 	A int    `default:"20_11_2002"`
 	B string `default:"gm"`
@@ -1054,3 +1315,154 @@ type testParseBool struct {
 type testParseBoolPtr struct {
 	BoolPtr *bool `default:"FAISE"`
 }
+
+// Named types for testing kind fallback
+type (
+	testPort     int
+	testLevel    string
+	testRatio    float32
+	testEnabled  bool
+	testTags     []string
+	testLabels   map[string]string
+	testTriple   [3]int
+	testHandler  func()
+	testSmallInt int8
+)
+
+// Struct for testing named types
+type testNamed struct {
+	Port     testPort      `default:"8080"`
+	Level    testLevel     `default:"debug"`
+	Ratio    testRatio     `default:"0.5"`
+	Enabled  testEnabled   `default:"true"`
+	Tags     testTags      `default:"[\"a\",\"b\"]"`
+	Labels   testLabels    `default:"{\"env\":\"prod\"}"`
+	Triple   testTriple    `default:"[1,2,3]"`
+	Timeout  time.Duration `default:"2s"`
+	PortPtr  *testPort     `default:"9090"`
+	LevelPtr *testLevel    `default:"info"`
+}
+
+// Struct for testing named type overflow
+type testNamedOverflow struct {
+	Small testSmallInt `default:"128"`
+}
+
+// Struct for testing named function type
+type testNamedFunc struct {
+	Handler testHandler `default:"some_value"`
+}
+
+// testColor is an enum implementing encoding.TextUnmarshaler
+type testColor int
+
+const (
+	testColorRed testColor = iota + 1
+	testColorBlue
+)
+
+func (c *testColor) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "red":
+		*c = testColorRed
+	case "blue":
+		*c = testColorBlue
+	default:
+		return fmt.Errorf("unknown color %q", text)
+	}
+	return nil
+}
+
+// Struct for testing encoding.TextUnmarshaler types
+type testTextUnmarshaler struct {
+	Addr    netip.Addr  `default:"10.0.0.1"`
+	AddrPtr *netip.Addr `default:"::1"`
+	Big     *big.Int    `default:"1267650600228229401496703205376"`
+	Level   slog.Level  `default:"WARN"`
+	Color   testColor   `default:"blue"`
+	Time    time.Time   `default:"2024-01-02T03:04:05Z"`
+	Unset   *time.Time
+}
+
+// Struct for testing encoding.TextUnmarshaler errors
+type testTextUnmarshalerError struct {
+	Color testColor `default:"purple"`
+}
+
+// testPair implements json.Unmarshaler from a "left|right" JSON string
+type testPair struct {
+	Left  string
+	Right string `default:"ignored"`
+}
+
+func (p *testPair) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	left, right, ok := strings.Cut(str, "|")
+	if !ok {
+		return fmt.Errorf("missing separator in %q", str)
+	}
+	p.Left, p.Right = left, right
+	return nil
+}
+
+// testRelease implements encoding.TextUnmarshaler from a "major.minor" string
+type testRelease struct {
+	Major int `default:"1"`
+	Minor int `default:"2"`
+}
+
+func (v *testRelease) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+// Struct for testing untagged structs with their own unmarshalers, which are recursed into
+type testUntaggedUnmarshalers struct {
+	Pair     testPair
+	PairPtr  *testPair
+	Version  testRelease
+	Versions []testRelease
+	Tagged   testRelease `default:"3.4"`
+}
+
+// Struct with nested defaults for testing JSON object defaults
+type testInner struct {
+	A int    `default:"10"`
+	B string `default:"default b"`
+	C string `default:"nested"`
+}
+
+// Struct for testing JSON object defaults on struct fields
+type testStructJSON struct {
+	Inner    testInner  `default:"{\"A\":1,\"B\":\"x\"}"`
+	InnerPtr *testInner `default:"{\"A\":2}"`
+	Pair     testPair   `default:"\"l|r\""`
+	PairPtr  *testPair  `default:"\"p|q\""`
+}
+
+// Struct for testing invalid JSON object defaults
+type testStructJSONError struct {
+	Inner testInner `default:"{A:1}"`
+}
+
+// Struct for testing defaults of collection elements
+type testCollections struct {
+	Slice   []testInner
+	Ptrs    []*testInner
+	Array   [2]testInner
+	Map     map[string]testInner
+	MapPtr  map[int]*testInner
+	Default []testInner `default:"[{\"A\":3}]"`
+}
+
+// Structs for testing errors in collection elements
+type testCollectionItem struct {
+	Port int8 `default:"300"`
+}
+
+type testCollectionError struct {
+	Items map[string]testCollectionItem
+}
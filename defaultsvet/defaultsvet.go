@@ -0,0 +1,130 @@
+// Package defaultsvet defines an Analyzer that checks default struct tags at build time.
+//
+// The analyzer reports tags that Defaults would reject at run time: values that do not
+// fit the field type (e.g. `default:"300"` on an int8), malformed literals (e.g. invalid
+// JSON on a slice), unsupported field types and invalid tag options. It also reports
+// default tags on unexported fields, which Defaults ignores.
+//
+// Tag values are validated with defaults.ValidateTag, so the rules are those of parser.go.
+// Fields whose parsing depends on run-time state, namely types implementing
+// encoding.TextUnmarshaler or json.Unmarshaler and types listed in the -skip flag
+// (e.g. those with parsers registered through defaults.RegisterParser), are not checked.
+//
+// Run it with go vet:
+//
+//	go install github.com/lthphuw/go-defaults/cmd/defaultsvet@latest
+//	go vet -vettool=$(which defaultsvet) ./...
+package defaultsvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	defaults "github.com/lthphuw/go-defaults"
+	"github.com/lthphuw/go-defaults/internal/typeconv"
+)
+
+const doc = `check default struct tags
+
+The defaultsvet analyzer reports default tags that would make defaults.Defaults
+fail at run time, such as values out of range for the field type, malformed
+JSON literals, unsupported field types and invalid tag options, as well as
+default tags on unexported fields.`
+
+// Analyzer reports invalid default struct tags.
+var Analyzer = &analysis.Analyzer{
+	Name:     "defaultsvet",
+	Doc:      doc,
+	URL:      "https://pkg.go.dev/github.com/lthphuw/go-defaults/defaultsvet",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	tagKey = defaults.Tag // -tag flag
+	skip   string         // -skip flag
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&tagKey, "tag", tagKey, "struct tag key holding default values")
+	Analyzer.Flags.StringVar(&skip, "skip", "", "comma-separated list of types with custom parsers to skip, e.g. net.IP,example.com/pkg.Celsius")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	var names []string
+	for name := range strings.SplitSeq(skip, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	c := typeconv.New(names...)
+
+	inspect.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		for _, field := range n.(*ast.StructType).Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			tag, ok := reflect.StructTag(raw).Lookup(tagKey)
+			if !ok {
+				continue
+			}
+			typ := pass.TypesInfo.TypeOf(field.Type)
+			if typ == nil {
+				continue
+			}
+			for _, name := range fieldNames(field, typ) {
+				checkField(pass, c, field.Tag.Pos(), name, typ, tag)
+			}
+		}
+	})
+	return nil, nil
+}
+
+// fieldNames returns the names declared by a struct field, or the type name for embedded fields.
+func fieldNames(field *ast.Field, typ types.Type) []string {
+	if len(field.Names) == 0 {
+		if ptr, ok := types.Unalias(typ).(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if named, ok := types.Unalias(typ).(*types.Named); ok {
+			return []string{named.Obj().Name()}
+		}
+		return nil
+	}
+	names := make([]string, len(field.Names))
+	for i, name := range field.Names {
+		names[i] = name.Name
+	}
+	return names
+}
+
+// checkField reports problems with the default tag of a single field.
+func checkField(pass *analysis.Pass, c *typeconv.Converter, pos token.Pos, name string, typ types.Type, tag string) {
+	if name == "_" {
+		return
+	}
+	if !ast.IsExported(name) {
+		pass.Reportf(pos, "default tag on unexported field %s is ignored", name)
+		return
+	}
+	rt := c.ReflectType(typ)
+	if rt == nil {
+		return
+	}
+	if err := defaults.ValidateTag(rt, tag); err != nil {
+		pass.Reportf(pos, "invalid default tag on field %s: %v", name, err)
+	}
+}
@@ -0,0 +1,16 @@
+package defaultsvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := Analyzer.Flags.Set("skip", "a.Custom"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Analyzer.Flags.Set("skip", "") })
+
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
@@ -0,0 +1,72 @@
+package a
+
+import (
+	"net/netip"
+	"time"
+)
+
+type Level int8
+
+type Inner struct {
+	Port uint16 `default:"70000"` // want `invalid default tag on field Port: .*value out of range`
+	Host string `default:"localhost"`
+}
+
+type Node struct {
+	Value int `default:"1"`
+	Next  *Node
+}
+
+type Config struct {
+	Name     string            `default:"app"`
+	Small    int8              `default:"300"` // want `invalid default tag on field Small: .*value out of range`
+	Count    int               `default:"ten"` // want `invalid default tag on field Count: .*invalid syntax`
+	Ratio    float64           `default:"0.5"`
+	Enabled  bool              `default:"yes"` // want `invalid default tag on field Enabled`
+	Level    Level             `default:"128"` // want `invalid default tag on field Level: .*value out of range`
+	Timeout  time.Duration     `default:"5s"`
+	Interval time.Duration     `default:"5 seconds"` // want `invalid default tag on field Interval`
+	Keep     time.Duration     `default:"7d"`
+	Period   time.Duration     `default:"P1M"` // want `invalid default tag on field Period: invalid syntax: years and months in "P1M" have no fixed duration`
+	Tags     []string          `default:"[\"a\",\"b\"]"`
+	Ports    []int             `default:"[1,2"` // want `invalid default tag on field Ports: invalid slice format`
+	Labels   map[string]string `default:"{\"a\":\"b\"}"`
+	Pair     [2]int            `default:"[1,2,3]"` // want `invalid default tag on field Pair: value out of range`
+	Addr     netip.Addr        `default:"not an address"`
+	Addrs    []netip.Addr      `default:"[\"10.0.0.1\"]"`
+	Inner    Inner             `default:"{\"Port\":80}"`
+	InnerPtr *Inner            `default:",noalloc"`
+	BadOpt   *Inner            `default:",alloc=sometimes"` // want `invalid default tag on field BadOpt: invalid tag option alloc=sometimes`
+	BadJSON  Inner             `default:"{\"Port\":\"x\"}"` // want `invalid default tag on field BadJSON: invalid struct format`
+	Head     *Node             `default:"{\"Value\":2,\"Next\":{\"Value\":3}}"`
+	Events   chan int          `default:"1"` // want `invalid default tag on field Events: unsupported type "chan"`
+	Handler  func()            `default:"x"` // want `invalid default tag on field Handler: unsupported type "func"`
+	Any      any               `default:"1"` // want `invalid default tag on field Any: unsupported type "interface"`
+	Home     string            `default:"${HOME}/data"`
+	EnvPort  int               `default:"${PORT:-8080}"`
+	EnvOnly  int               `default:"${PORT}"`
+	BadEnv   int               `default:"${PORT:-x}"` // want `invalid default tag on field BadEnv: .*invalid syntax`
+	AdminURL string            `default:"http://${.Name}:${.EnvPort}"`
+	Unclosed string            `default:"${HOME"` // want `invalid default tag on field Unclosed: invalid syntax: unterminated variable reference`
+	Start    time.Time         `default:"2024-01-01,layout=2006-01-02"`
+	Month    time.Month        `default:"Febuary"` // want `invalid default tag on field Month: month "Febuary": invalid syntax`
+	Weekday  time.Weekday      `default:"mon"`
+	Zone     *time.Location    `default:"UTC"`
+	BadZone  *time.Location    `default:"Mars/Olympus"` // want `invalid default tag on field BadZone: invalid syntax: unknown time zone Mars/Olympus`
+	Buffer   int64             `default:"64MiB,quantity"`
+	Window   uint16            `default:"64Ki,quantity"` // want `invalid default tag on field Window: .*value out of range`
+	Nested   struct {
+		Retries uint8 `default:"-1"` // want `invalid default tag on field Retries`
+	}
+	hidden string `default:"x"` // want `default tag on unexported field hidden is ignored`
+	Plain  int
+	Other  int `json:"other"`
+}
+
+type Custom struct {
+	Degrees float64
+}
+
+type WithCustom struct {
+	Temp Custom `default:"21.5C"`
+}
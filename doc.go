@@ -1,7 +1,13 @@
 // Package defaults provides functionality to parse and set default values for struct fields based on their "default" tags.
 // The Defaults function processes a non-nil pointer to a struct, setting default values for exported fields that are unset
-// (zero values for non-pointers or nil for pointers) using the tag key specified by the package-level variable Tag (defaulting to "default").
-// Nested structs are processed recursively. Fields without a "default" tag are skipped unless they are structs or struct pointers.
+// (nil for pointers, not set for types implementing Presence such as Opt, reporting true from their own IsZero() bool
+// method such as time.Time, or else zero values, with empty maps and slices as chosen by SetEmptyMode) using the tag key
+// specified by the package-level variable Tag (defaulting to "default").
+// Nested structs are processed recursively; nil struct pointers are allocated unless doing so would repeat a type already on
+// the current path, as configured by SetCyclePolicy. Fields without a "default" tag are skipped unless they are structs or struct pointers,
+// or slices, arrays or maps of them.
+// Struct and non-nil struct pointer elements of slices, arrays and map values are processed the same way, including elements set
+// from the field's own default.
 //
 // Supported field types and example default tags:
 //   - int: `default:"123"`
@@ -23,7 +29,7 @@
 //   - map (e.g., map[string]any): `default:"{\"key\":\"value\",\"num\":42}"`
 //   - slice (e.g., []string): `default:"[\"a\",\"b\",\"c\"]"`
 //   - array (e.g., [3]int): `default:"[1,2,3]"`
-//   - struct (triggers recursive default setting for nested struct fields)
+//   - struct (triggers recursive default setting for nested struct fields): `default:"{\"a\":1,\"b\":\"x\"}"`
 //   - Pointers to the above types (e.g., *int, *string, *map[string]any, *[]string, *[3]int, *struct):
 //   - *int: `default:"123"`
 //   - *string: `default:"hello"`
@@ -31,6 +37,15 @@
 //   - *[]string: `default:"[\"a\",\"b\"]"`
 //   - *[3]int: `default:"[1,2,3]"`
 //   - *struct (recursively processes nested struct fields)
+//   - Named types whose underlying type is one of the above (e.g., type Port int, type Tags []string)
+//   - Types implementing encoding.TextUnmarshaler (e.g., netip.Addr, *big.Int, slog.Level): `default:"10.0.0.1"`
+//   - Types implementing json.Unmarshaler: `default:"\"value\""`
+//   - Opt[T] for any supported T, filled only when not set: `default:"true"`
+//   - time.Duration, with the d and w units or in ISO 8601: `default:"7d"`, `default:"PT1H30M"`
+//   - time.Time, in RFC 3339, with the layout tag option or relative to the clock: `default:"now+24h"`
+//   - *time.Location: `default:"America/New_York"`
+//   - time.Month and time.Weekday, by name or number: `default:"March"`
+//   - ByteSize, with SI or IEC units: `default:"64MiB"`
 //
 // Unsupported field types:
 //   - Function types (e.g., func(), *func())
@@ -39,8 +54,42 @@
 //   - Unsafe pointers (e.g., unsafe.Pointer)
 //   - Any other types not listed above
 //
+// Integer and float fields accept quantities with SI and IEC units, such as `default:"1.5GB,quantity"` or
+// `default:"10k,quantity"`, with the quantity tag option or after SetQuantitySyntax(true).
+//
+// Relative times and the @now provider read the clock set with SetClock, time.Now by default, and are resolved on every call.
+//
+// With the shorthand syntax, selected by SetSyntax or the `syntax=short` tag option, slices and arrays are written as
+// comma-separated elements (`default:"a,b,c"`) and maps as key:value pairs (`default:"a:1,b:2"`).
+//
 // Default tag values must be valid for the field's type. Numeric types require valid numeric strings, bool requires "true" or "false",
-// strings can be plain or JSON-escaped, and maps/slices/arrays require JSON-formatted strings. Errors are returned for invalid inputs,
+// strings can be plain or JSON-escaped, and maps/slices/arrays/structs require JSON-formatted strings. A JSON default on a struct
+// is decoded first and the nested struct's own default tags then fill the fields it left unset. Errors are returned for invalid inputs,
 // unexported fields, empty tags (for non-struct fields), parsing failures (e.g., invalid number formats, JSON syntax errors),
 // out-of-range values, or unsupported types. The Defaults function recursively processes nested structs to apply their default tags.
+//
+// Tag values may reference environment variables as ${VAR} or ${VAR:-fallback}, with $$ for a literal $.
+// They are expanded on every call through the function set with SetLookup, os.LookupEnv by default.
+// Tag values starting with @, as in @now or @env(HOME), call a provider registered with RegisterProvider
+// when the default is applied; @@ stands for a literal @.
+// References to other fields, ${.Field} for a sibling or ${..Field} for a field of the enclosing struct,
+// are resolved once the rest of the struct tree was defaulted, in dependency order.
+//
+// Which fields receive their default is controlled by an ApplyPolicy, set globally with SetApplyPolicy or per field with
+// the `policy=always|ifzero|ifnil|never` tag option. Reset restores every tagged field to its default.
+//
+// Field failures are returned as *FieldError, holding the dotted path of the field from the root struct.
+// Use errors.Is with ErrOutOfRange, ErrSyntax, ErrUnsupportedType or ErrCycle to branch on the cause,
+// and ErrInvalidInput for values that are not non-nil pointers to structs.
+// With an ErrorPolicy that collects errors, every failing field is reported in a *MultiError.
+//
+// Structs implementing Setter or ErrorSetter compute further defaults in their SetDefaults method, which is called
+// after the tag defaults of the struct and of its nested structs, so children are called before their parents.
+//
+// Types with a SetDefaults method generated by cmd/defaults-gen implement Generated and are defaulted
+// without reflection when the default settings are in use.
+//
+// The package-level Defaults function uses shared settings. New returns a Defaulter with its own tag key, parsers,
+// policies and hooks, configured through Option values, whose Apply method follows the same rules.
+// Make, MakePtr, MustMake and MakeOr return a defaulted value of a struct type, optionally configured by the same options.
 package defaults
@@ -0,0 +1,344 @@
+package defaults
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// unmarshalJSON decodes the JSON str into the value val points to. Durations at
+// any depth, that is values of types parsed by ParseDuration, also accept the
+// strings of ParseDuration, as in ["7d"] or {"Timeout":"PT1M"}.
+func unmarshalJSON(str string, val reflect.Value) error {
+	data := []byte(str)
+	if json.Valid(data) && hasDurations(val.Type().Elem(), make(map[reflect.Type]bool)) {
+		// Replace the duration strings by numbers of nanoseconds
+		var tree any
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return err
+		}
+		tree, err := durationsToNumbers(tree, val.Type().Elem())
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(tree); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, val.Interface())
+}
+
+// isDuration reports whether values of type t are parsed by ParseDuration.
+func isDuration(t reflect.Type) bool {
+	fn, ok := parsers.lookup(t)
+	return ok && sameFunc(fn, ParseDuration)
+}
+
+// jsonElem returns the type a JSON value decoded into a t is decoded into in turn:
+// the element type of pointers and Opt values, or t itself.
+func jsonElem(t reflect.Type) reflect.Type {
+	for {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			continue
+		}
+		if opt, ok := reflect.New(t).Interface().(optional); ok {
+			t = opt.valueType()
+			continue
+		}
+		return t
+	}
+}
+
+// decodesItself reports whether t is decoded by its own UnmarshalJSON or UnmarshalText method.
+func decodesItself(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// hasDurations reports whether JSON decoded into a t may hold durations.
+func hasDurations(t reflect.Type, seen map[reflect.Type]bool) bool {
+	t = jsonElem(t)
+	if isDuration(t) {
+		return true
+	}
+	if seen[t] || decodesItself(t) {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasDurations(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(t) {
+			if f.IsExported() && hasDurations(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// durationsToNumbers replaces the strings of the JSON value v, decoded with numbers
+// as json.Number, that are decoded into durations as part of a t by their number
+// of nanoseconds.
+func durationsToNumbers(v any, t reflect.Type) (any, error) {
+	t = jsonElem(t)
+	if isDuration(t) {
+		str, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		d, err := parseDuration(str)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatInt(int64(d), 10)), nil
+	}
+	if decodesItself(t) {
+		return v, nil
+	}
+
+	var err error
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if elems, ok := v.([]any); ok {
+			for i := range elems {
+				if elems[i], err = durationsToNumbers(elems[i], t.Elem()); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case reflect.Map, reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			var elem reflect.Type
+			if t.Kind() == reflect.Map {
+				elem = t.Elem()
+			} else if f, ok := jsonField(t, key); ok {
+				elem = f.Type
+			} else {
+				continue
+			}
+			if obj[key], err = durationsToNumbers(obj[key], elem); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// jsonField returns the field of struct type t that the JSON decoder fills from
+// key: the field named key by its json tag or its name, ignoring case if none matches exactly.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	found := false
+	for _, f := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" || f.Anonymous && name == "" && jsonElem(f.Type).Kind() == reflect.Struct {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = f, true
+		}
+	}
+	return folded, found
+}
+
+// parseDuration parses the duration syntax of ParseDuration: time.ParseDuration,
+// extended with the d and w units, or ISO 8601.
+func parseDuration(str string) (time.Duration, error) {
+	sign, rest := time.Duration(1), str
+	if strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+		if rest[0] == '-' {
+			sign = -1
+		}
+		rest = rest[1:]
+	}
+	var d time.Duration
+	var err error
+	switch {
+	case strings.HasPrefix(rest, "P"):
+		d, err = parseISODuration(str, rest[1:])
+	case strings.ContainsAny(rest, "dw"):
+		d, err = parseDayDuration(str, rest)
+	default:
+		// Plain Go syntax, with the errors of the standard library
+		d, err = time.ParseDuration(str)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrSyntax, err)
+		}
+		return d, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return sign * d, nil
+}
+
+// parseDayDuration parses a sequence of decimal numbers with units, such as 1w2d12h,
+// where the units of time.ParseDuration are extended with d (24h) and w (7d).
+func parseDayDuration(str, rest string) (time.Duration, error) {
+	var total time.Duration
+	for rest != "" {
+		num := rest[:skipNumber(rest, 0)]
+		if !isDecimal(num) {
+			return 0, fmt.Errorf("%w: time: invalid duration %q", ErrSyntax, str)
+		}
+		rest = rest[len(num):]
+		unit := rest
+		if i := strings.IndexAny(rest, ".0123456789"); i >= 0 {
+			unit = rest[:i]
+		}
+		rest = rest[len(unit):]
+
+		var d time.Duration
+		var err error
+		switch unit {
+		case "d":
+			d, err = scaleDuration(str, num, day)
+		case "w":
+			d, err = scaleDuration(str, num, week)
+		case "ns", "us", "µs", "μs", "ms", "s", "m", "h":
+			if d, err = time.ParseDuration(num + unit); err != nil {
+				// num is well-formed, so only its size can fail
+				err = fmt.Errorf("%w: duration %q overflows time.Duration", ErrOutOfRange, str)
+			}
+		case "":
+			return 0, fmt.Errorf("%w: time: missing unit in duration %q", ErrSyntax, str)
+		default:
+			return 0, fmt.Errorf("%w: time: unknown unit %q in duration %q", ErrSyntax, unit, str)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if total, err = addDuration(str, total, d); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// isoDesignators lists the designators of an ISO 8601 duration in their required
+// order, with the length of their unit. Years and months have no fixed length.
+var isoDesignators = []struct {
+	name byte
+	time bool // after the T separator
+	unit time.Duration
+}{
+	{'Y', false, 0},
+	{'M', false, 0},
+	{'W', false, week},
+	{'D', false, day},
+	{'H', true, time.Hour},
+	{'M', true, time.Minute},
+	{'S', true, time.Second},
+}
+
+// parseISODuration parses the part of an ISO 8601 duration after its P, such as
+// 7D or T1H30M for P7D and PT1H30M.
+func parseISODuration(str, rest string) (time.Duration, error) {
+	if rest == "" || rest == "T" || strings.HasSuffix(rest, "T") {
+		return 0, fmt.Errorf("%w: invalid ISO 8601 duration %q", ErrSyntax, str)
+	}
+	var total time.Duration
+	inTime, next := false, 0
+	for rest != "" {
+		if rest[0] == 'T' && !inTime {
+			inTime, rest = true, rest[1:]
+			continue
+		}
+		num := rest[:skipNumber(rest, 0)]
+		if !isDecimal(num) || len(num) == len(rest) {
+			return 0, fmt.Errorf("%w: invalid ISO 8601 duration %q", ErrSyntax, str)
+		}
+		name := rest[len(num)]
+		rest = rest[len(num)+1:]
+
+		i := next
+		for i < len(isoDesignators) && (isoDesignators[i].name != name || isoDesignators[i].time != inTime) {
+			i++
+		}
+		if i == len(isoDesignators) {
+			return 0, fmt.Errorf("%w: invalid ISO 8601 duration %q", ErrSyntax, str)
+		}
+		if isoDesignators[i].unit == 0 {
+			return 0, fmt.Errorf("%w: years and months in %q have no fixed duration", ErrSyntax, str)
+		}
+		next = i + 1
+
+		d, err := scaleDuration(str, num, isoDesignators[i].unit)
+		if err != nil {
+			return 0, err
+		}
+		if total, err = addDuration(str, total, d); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// skipNumber returns the index of the first byte of str at or after i that
+// is not part of an unsigned decimal number.
+func skipNumber(str string, i int) int {
+	for i < len(str) && ('0' <= str[i] && str[i] <= '9' || str[i] == '.') {
+		i++
+	}
+	return i
+}
+
+// isDecimal reports whether num is an unsigned decimal number, such as 7, 1.5 or .5.
+func isDecimal(num string) bool {
+	return strings.Trim(num, ".") != "" && strings.Count(num, ".") <= 1
+}
+
+// scaleDuration returns num units, where num is an unsigned decimal number.
+func scaleDuration(str, num string, unit time.Duration) (time.Duration, error) {
+	// Parse num as hours, or smaller units, to keep the fraction exact
+	base, suffix := time.Hour, "h"
+	switch {
+	case unit < time.Minute:
+		base, suffix = time.Second, "s"
+	case unit < time.Hour:
+		base, suffix = time.Minute, "m"
+	}
+	d, err := time.ParseDuration(num + suffix)
+	n := unit / base
+	if err != nil || d > math.MaxInt64/n {
+		// num is well-formed, so only its size can fail
+		return 0, fmt.Errorf("%w: duration %q overflows time.Duration", ErrOutOfRange, str)
+	}
+	return d * n, nil
+}
+
+// addDuration returns a+b for non-negative durations.
+func addDuration(str string, a, b time.Duration) (time.Duration, error) {
+	if a > math.MaxInt64-b {
+		return 0, fmt.Errorf("%w: duration %q overflows time.Duration", ErrOutOfRange, str)
+	}
+	return a + b, nil
+}
@@ -0,0 +1,184 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDurations struct {
+	Retention time.Duration              `default:"7d"`
+	Grace     *time.Duration             `default:"1w2d"`
+	Interval  Opt[time.Duration]         `default:"PT1H30M"`
+	Backoff   []time.Duration            `default:"1s,1d,P1W,syntax=short"`
+	Windows   map[string]time.Duration   `default:"day:1d,week:P7D,syntax=short"`
+	Expires   time.Time                  `default:"now+7d"`
+	Timeouts  []time.Duration            `default:"[\"7d\", 1000000000, \"PT1M\"]"`
+	Limits    map[string]time.Duration   `default:"{\"read\":\"1w\",\"write\":\"90s\"}"`
+	Steps     [3]time.Duration           `default:"[\"1d\",\"P1W\"]"`
+	Nested    map[string][]time.Duration `default:"{\"a\":[\"7d\",\"PT1H\"]}"`
+	Pointers  []*time.Duration           `default:"[\"1d\",null]"`
+	Holder    testDurationHolder         `default:"{\"T\":\"7d\",\"opt\":\"1h\",\"grace\":\"2d\",\"Raw\":60000000000}"`
+}
+
+type testDurationHolder struct {
+	T     time.Duration
+	Opt   Opt[time.Duration]
+	Grace *time.Duration `json:"grace"`
+	Raw   time.Duration
+	Text  testColor `default:"red"`
+}
+
+// testTimeout is a named duration, parsed as one once registered with ParseDuration
+type testTimeout time.Duration
+
+func TestExtendedDurations(t *testing.T) {
+	got := &testDurations{}
+	if err := New(WithClock(func() time.Time { return testNow })).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	grace, oneDay, twoDays := 9*day, day, 2*day
+	want := &testDurations{
+		Retention: 7 * day,
+		Grace:     &grace,
+		Interval:  Some(90 * time.Minute),
+		Backoff:   []time.Duration{time.Second, day, week},
+		Windows:   map[string]time.Duration{"day": day, "week": week},
+		Expires:   testNow.Add(7 * day),
+		Timeouts:  []time.Duration{7 * day, time.Second, time.Minute},
+		Limits:    map[string]time.Duration{"read": week, "write": 90 * time.Second},
+		Steps:     [3]time.Duration{day, week},
+		Nested:    map[string][]time.Duration{"a": {7 * day, time.Hour}},
+		Pointers:  []*time.Duration{&oneDay, nil},
+		Holder:    testDurationHolder{T: 7 * day, Opt: Some(time.Hour), Grace: &twoDays, Raw: time.Minute, Text: testColorRed},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseExtendedDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      time.Duration
+		wantIs    error
+		errString string
+	}{
+		{name: "Go syntax", input: "1h30m", want: 90 * time.Minute},
+		{name: "days", input: "7d", want: 7 * day},
+		{name: "weeks", input: "2w", want: 2 * week},
+		{name: "mixed units", input: "1w1d1h1m1s1ms", want: 8*day + time.Hour + time.Minute + time.Second + time.Millisecond},
+		{name: "fractional day", input: "0.5d", want: 12 * time.Hour},
+		{name: "negative", input: "-2d", want: -2 * day},
+		{name: "ISO days", input: "P7D", want: 7 * day},
+		{name: "ISO weeks", input: "P1W", want: week},
+		{name: "ISO date and time", input: "P1DT12H", want: 36 * time.Hour},
+		{name: "ISO time", input: "PT1H30M", want: 90 * time.Minute},
+		{name: "ISO fractional seconds", input: "PT0.5S", want: 500 * time.Millisecond},
+		{name: "ISO minutes are not months", input: "PT5M", want: 5 * time.Minute},
+		{name: "ISO negative", input: "-P1D", want: -day},
+		{name: "unknown unit", input: "1d2y", wantIs: ErrSyntax, errString: `invalid syntax: time: unknown unit "y" in duration "1d2y"`},
+		{name: "missing unit", input: "1d2", wantIs: ErrSyntax, errString: `invalid syntax: time: missing unit in duration "1d2"`},
+		{name: "invalid number", input: "1..5d", wantIs: ErrSyntax, errString: `invalid syntax: time: invalid duration "1..5d"`},
+		{name: "ISO years", input: "P1Y", wantIs: ErrSyntax, errString: `invalid syntax: years and months in "P1Y" have no fixed duration`},
+		{name: "ISO months", input: "P1M", wantIs: ErrSyntax, errString: `invalid syntax: years and months in "P1M" have no fixed duration`},
+		{name: "ISO empty", input: "P", wantIs: ErrSyntax, errString: `invalid syntax: invalid ISO 8601 duration "P"`},
+		{name: "ISO empty time", input: "P1DT", wantIs: ErrSyntax, errString: `invalid syntax: invalid ISO 8601 duration "P1DT"`},
+		{name: "ISO out of order", input: "PT1M1H", wantIs: ErrSyntax, errString: `invalid syntax: invalid ISO 8601 duration "PT1M1H"`},
+		{name: "ISO hours before T", input: "P1H", wantIs: ErrSyntax, errString: `invalid syntax: invalid ISO 8601 duration "P1H"`},
+		{name: "overflow", input: "1000000w", wantIs: ErrOutOfRange, errString: `value out of range: duration "1000000w" overflows time.Duration`},
+		{name: "overflow in sum", input: "15000w100000h", wantIs: ErrOutOfRange, errString: `value out of range: duration "15000w100000h" overflows time.Duration`},
+		{name: "ISO overflow", input: "P1000000W", wantIs: ErrOutOfRange, errString: `value out of range: duration "P1000000W" overflows time.Duration`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.input, reflect.TypeFor[time.Duration]())
+			if tt.wantIs != nil {
+				if !errors.Is(err, tt.wantIs) {
+					t.Errorf("ParseDuration() error = %v, want %v", err, tt.wantIs)
+				}
+				if err == nil || !strings.HasPrefix(err.Error(), tt.errString) {
+					t.Errorf("ParseDuration() error = %v, want %q", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration() error = %v", err)
+			}
+			if got.Interface() != tt.want {
+				t.Errorf("ParseDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONNamedDurations(t *testing.T) {
+	RegisterParser(reflect.TypeFor[testTimeout](), ParseDuration)
+	t.Cleanup(func() { UnregisterParser(reflect.TypeFor[testTimeout]()) })
+	got := &struct {
+		One  testTimeout            `default:"7d"`
+		Many map[string]testTimeout `default:"{\"a\":\"1w\"}"`
+	}{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if got.One != testTimeout(7*day) || got.Many["a"] != testTimeout(week) {
+		t.Errorf("Defaults() got = %+v", got)
+	}
+}
+
+func TestJSONDurationErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		wantIs    error
+		errString string
+	}{
+		{
+			name: "slice",
+			input: &struct {
+				V []time.Duration `default:"[\"1d\", \"2y\"]"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid slice format: invalid syntax: time: unknown unit "y" in duration "2y"`,
+		},
+		{
+			name: "map",
+			input: &struct {
+				V map[string]time.Duration `default:"{\"a\":\"P1M\"}"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid map format: invalid syntax: years and months in "P1M" have no fixed duration`,
+		},
+		{
+			name: "struct field",
+			input: &struct {
+				V testDurationHolder `default:"{\"T\":\"1x\"}"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid struct format: invalid syntax: time: unknown unit "x" in duration "1x"`,
+		},
+		{
+			name: "array overflow",
+			input: &struct {
+				V [1]time.Duration `default:"[\"1000000w\"]"`
+			}{},
+			wantIs:    ErrOutOfRange,
+			errString: `failed to set default for field V: invalid array format: value out of range: duration "1000000w" overflows time.Duration`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("Defaults() error = %v, want %v", err, tt.wantIs)
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errString) {
+				t.Errorf("Defaults() error = %v, want prefix %q", err, tt.errString)
+			}
+		})
+	}
+}
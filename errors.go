@@ -0,0 +1,114 @@
+package defaults
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/encoding/json"
+)
+
+var (
+	// ErrInvalidInput is returned when the value passed to Defaults or Apply
+	// is not a non-nil pointer to a struct.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrOutOfRange matches errors for default values that do not fit the field type,
+	// including strconv.ErrRange from the numeric parsers.
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrSyntax matches errors for malformed default values, including strconv.ErrSyntax
+	// from the numeric parsers and JSON decoding errors.
+	ErrSyntax = errors.New("invalid syntax")
+	// ErrReference matches errors for ${.Field} references in default tags
+	// that cannot be resolved.
+	ErrReference = errors.New("unresolved reference")
+)
+
+// FieldError describes a failure to set the default value of a field.
+// Use errors.As to retrieve it and errors.Is with ErrOutOfRange, ErrSyntax,
+// ErrUnsupportedType or ErrCycle to branch on the cause.
+type FieldError struct {
+	Path   string       // path of the field from the root struct, e.g. Server.TLS.Ports[2]
+	Struct string       // type of the struct declaring the field, or whose SetDefaults method failed
+	Type   reflect.Type // type of the field
+	Tag    string       // raw tag value, including options
+	Err    error        // underlying error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		// SetDefaults method of the root struct
+		return fmt.Sprintf("failed to set defaults for %s: %v", e.Struct, e.Err)
+	}
+	return fmt.Sprintf("failed to set default for field %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the underlying error belongs to the category of target,
+// mapping errors from strconv and the JSON decoder to ErrOutOfRange and ErrSyntax.
+func (e *FieldError) Is(target error) bool {
+	switch target {
+	case ErrOutOfRange:
+		return errors.Is(e.Err, strconv.ErrRange)
+	case ErrSyntax:
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		return errors.Is(e.Err, strconv.ErrSyntax) || errors.As(e.Err, &syntaxErr) || errors.As(e.Err, &typeErr)
+	default:
+		return false
+	}
+}
+
+// ErrorPolicy controls whether Defaults stops at the first failing field.
+// The zero value stops at the first failure.
+type ErrorPolicy struct {
+	// Collect keeps processing the remaining fields after a failure
+	// and returns all failures as a *MultiError.
+	Collect bool
+	// MaxErrors stops processing at the first failure beyond this many.
+	// Zero means no limit.
+	MaxErrors int
+}
+
+// SetErrorPolicy sets the policy used by Defaults for failing fields.
+// Use WithErrorPolicy to configure a Defaulter instead.
+func SetErrorPolicy(p ErrorPolicy) {
+	std.errors = p
+}
+
+// MultiError lists the failures collected under an ErrorPolicy with Collect set,
+// in struct declaration order. Like the result of errors.Join, it unwraps to
+// the individual errors, so errors.Is and errors.As match any of them.
+type MultiError struct {
+	Errors    []error // failures, each usually a *FieldError
+	Truncated bool    // whether more failures than ErrorPolicy.MaxErrors occurred
+}
+
+// Error implements the error interface, listing one failure per line.
+func (e *MultiError) Error() string {
+	var b strings.Builder
+	for i, err := range e.Errors {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	if e.Truncated {
+		b.WriteString("\ntoo many errors")
+	}
+	return b.String()
+}
+
+// Unwrap returns the collected errors.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// errTooMany aborts a walk once ErrorPolicy.MaxErrors failures were collected.
+var errTooMany = errors.New("too many errors")
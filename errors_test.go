@@ -0,0 +1,258 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testErrInner struct {
+	Port  uint8 `default:"300"`
+	Count int   `default:"abc"`
+}
+
+type testErrOuter struct {
+	Name  string `default:"outer"`
+	Inner *testErrInner
+}
+
+func TestFieldError(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      any
+		wantPath   string
+		wantStruct string
+		wantType   reflect.Type
+		wantTag    string
+		wantIs     error
+		errString  string
+	}{
+		{
+			name: "out of range",
+			input: &struct {
+				Port uint8 `default:"300"`
+			}{},
+			wantPath:   "Port",
+			wantStruct: "struct { Port uint8 \"default:\\\"300\\\"\" }",
+			wantType:   reflect.TypeFor[uint8](),
+			wantTag:    "300",
+			wantIs:     ErrOutOfRange,
+			errString:  "failed to set default for field Port: ",
+		},
+		{
+			name:       "nested path",
+			input:      &testErrOuter{Inner: &testErrInner{Port: 1}},
+			wantPath:   "Inner.Count",
+			wantStruct: "defaults.testErrInner",
+			wantType:   reflect.TypeFor[int](),
+			wantTag:    "abc",
+			wantIs:     ErrSyntax,
+			errString:  "failed to set default for field Inner.Count: ",
+		},
+		{
+			name: "invalid json",
+			input: &struct {
+				Tags []string `default:"[a"`
+			}{},
+			wantPath:  "Tags",
+			wantType:  reflect.TypeFor[[]string](),
+			wantTag:   "[a",
+			wantIs:    ErrSyntax,
+			errString: "failed to set default for field Tags: ",
+		},
+		{
+			name: "unsupported type",
+			input: &struct {
+				Ch chan int `default:"1"`
+			}{},
+			wantPath:  "Ch",
+			wantType:  reflect.TypeFor[chan int](),
+			wantTag:   "1",
+			wantIs:    ErrUnsupportedType,
+			errString: `failed to set default for field Ch: unsupported type "chan"`,
+		},
+		{
+			name: "tag option",
+			input: &struct {
+				Inner *testErrInner `default:",alloc=sometimes"`
+			}{},
+			wantPath:  "Inner",
+			wantType:  reflect.TypeFor[*testErrInner](),
+			wantTag:   ",alloc=sometimes",
+			errString: "failed to set default for field Inner: invalid tag option alloc=sometimes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Defaults() error = %v, want *FieldError", err)
+			}
+			if fe.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", fe.Path, tt.wantPath)
+			}
+			if tt.wantStruct != "" && fe.Struct != tt.wantStruct {
+				t.Errorf("Struct = %q, want %q", fe.Struct, tt.wantStruct)
+			}
+			if fe.Type != tt.wantType {
+				t.Errorf("Type = %v, want %v", fe.Type, tt.wantType)
+			}
+			if fe.Tag != tt.wantTag {
+				t.Errorf("Tag = %q, want %q", fe.Tag, tt.wantTag)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if !strings.HasPrefix(err.Error(), tt.errString) {
+				t.Errorf("Defaults() error = %q, want prefix %q", err, tt.errString)
+			}
+		})
+	}
+}
+
+func TestFieldErrorIs(t *testing.T) {
+	err := Defaults(&struct {
+		Port uint8 `default:"300"`
+	}{})
+	if errors.Is(err, ErrSyntax) {
+		t.Errorf("errors.Is(%v, ErrSyntax) = true, want false", err)
+	}
+	if errors.Is(err, ErrCycle) {
+		t.Errorf("errors.Is(%v, ErrCycle) = true, want false", err)
+	}
+}
+
+func TestCycleFieldError(t *testing.T) {
+	d := New(WithCyclePolicy(CyclePolicy{Action: CycleError}), WithAllocPolicy(AllocAlways))
+	err := d.Apply(&testNode{})
+	var fe *FieldError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrCycle) {
+		t.Fatalf("Apply() error = %v, want *FieldError wrapping ErrCycle", err)
+	}
+	if fe.Path != "Next" {
+		t.Errorf("Path = %q, want %q", fe.Path, "Next")
+	}
+}
+
+func TestInvalidInputError(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{name: "nil", input: nil},
+		{name: "non-pointer", input: testErrOuter{}},
+		{name: "pointer to non-struct", input: new(int)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Defaults(tt.input); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("Defaults() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+type testErrConfig struct {
+	Name    string `default:"app"`
+	Port    uint8  `default:"300"`
+	Server  testErrInner
+	Timeout int `default:"1s"`
+}
+
+func TestErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    ErrorPolicy
+		want      *testErrConfig
+		wantPaths []string
+		truncated bool
+	}{
+		{
+			name:      "fail fast",
+			policy:    ErrorPolicy{},
+			want:      &testErrConfig{Name: "app"},
+			wantPaths: []string{"Port"},
+		},
+		{
+			name:      "collect all",
+			policy:    ErrorPolicy{Collect: true},
+			want:      &testErrConfig{Name: "app"},
+			wantPaths: []string{"Port", "Server.Port", "Server.Count", "Timeout"},
+		},
+		{
+			name:      "collect with cap",
+			policy:    ErrorPolicy{Collect: true, MaxErrors: 2},
+			want:      &testErrConfig{Name: "app"},
+			wantPaths: []string{"Port", "Server.Port"},
+			truncated: true,
+		},
+		{
+			name:      "cap not reached",
+			policy:    ErrorPolicy{Collect: true, MaxErrors: 4},
+			want:      &testErrConfig{Name: "app"},
+			wantPaths: []string{"Port", "Server.Port", "Server.Count", "Timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &testErrConfig{}
+			err := New(WithErrorPolicy(tt.policy)).Apply(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() got = %+v, want %+v", got, tt.want)
+			}
+
+			var errs []error
+			var multi *MultiError
+			if errors.As(err, &multi) {
+				if !tt.policy.Collect {
+					t.Fatalf("Apply() error = %T, want *FieldError", err)
+				}
+				errs = multi.Errors
+				if multi.Truncated != tt.truncated {
+					t.Errorf("Truncated = %v, want %v", multi.Truncated, tt.truncated)
+				}
+			} else if err != nil {
+				errs = []error{err}
+			}
+
+			var paths []string
+			for _, err := range errs {
+				var fe *FieldError
+				if !errors.As(err, &fe) {
+					t.Fatalf("error = %v, want *FieldError", err)
+				}
+				paths = append(paths, fe.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("paths = %v, want %v", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	err := New(WithErrorPolicy(ErrorPolicy{Collect: true})).Apply(&testErrConfig{})
+	if !errors.Is(err, ErrOutOfRange) || !errors.Is(err, ErrSyntax) {
+		t.Errorf("errors.Is(%v) does not match both ErrOutOfRange and ErrSyntax", err)
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "Port" {
+		t.Errorf("errors.As() = %v, want first failure at Port", fe)
+	}
+	if got := strings.Count(err.Error(), "\n") + 1; got != 4 {
+		t.Errorf("Error() has %d lines, want 4:\n%v", got, err)
+	}
+
+	err = New(WithErrorPolicy(ErrorPolicy{Collect: true, MaxErrors: 1})).Apply(&testErrConfig{})
+	if !strings.HasSuffix(err.Error(), "\ntoo many errors") {
+		t.Errorf("Error() = %q, want suffix %q", err, "too many errors")
+	}
+
+	if err := New(WithErrorPolicy(ErrorPolicy{Collect: true})).Apply(&testServer{}); err != nil {
+		t.Errorf("Apply() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,31 @@
+package defaults
+
+// Generated is implemented by types whose SetDefaults method was generated by the
+// defaults-gen command. DefaultsTag returns the struct tag key the method was
+// generated from.
+//
+// Generated methods apply the tags with plain assignments of pre-parsed values,
+// without reflection. Apply calls them instead of walking the struct when its
+// settings match those the code was generated for: the same tag key, the default
+// policies, no hooks and no custom parsers. Reset never calls them.
+//
+//	//go:generate go run github.com/lthphuw/go-defaults/cmd/defaults-gen -type=Config
+type Generated interface {
+	SetDefaults() error
+	DefaultsTag() string
+}
+
+// useGenerated reports whether Apply can call the generated SetDefaults method of g
+// instead of walking the struct.
+func (d *Defaulter) useGenerated(g Generated) bool {
+	return g.DefaultsTag() == d.tagKey() &&
+		d.policy == ApplyIfZero &&
+		d.cycle == CyclePolicy{} &&
+		d.alloc == AllocAlways &&
+		d.empty == EmptyNil &&
+		d.syntax == SyntaxJSON &&
+		!d.quantity &&
+		len(d.hooks) == 0 &&
+		len(d.parsers) == 0 &&
+		parsers.isBuiltin()
+}
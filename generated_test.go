@@ -0,0 +1,168 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+//go:generate go run ./cmd/defaults-gen -type=generatedTest
+
+// generatedTest has the fields of test and a SetDefaults method generated by defaults-gen.
+type generatedTest test
+
+func TestGeneratedConformance(t *testing.T) {
+	intVal, str := 7, "set"
+	tests := []struct {
+		name  string
+		input test
+	}{
+		{
+			name:  "empty",
+			input: test{},
+		},
+		{
+			name: "partially set",
+			input: test{
+				Int:       42,
+				String:    "custom",
+				Slice:     []string{"x"},
+				Duration:  time.Second,
+				Struct:    testStruct{A: 1},
+				IntPtr:    &intVal,
+				StringPtr: &str,
+				StructPtr: &testStruct{B: "b"},
+			},
+		},
+		{
+			name: "empty collections are set",
+			input: test{
+				Map:   map[string]any{},
+				Slice: []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.input
+			if err := Defaults(&want); err != nil {
+				t.Fatalf("Defaults() error = %v", err)
+			}
+			got := generatedTest(tt.input)
+			if err := got.SetDefaults(); err != nil {
+				t.Fatalf("SetDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(test(got), want) {
+				t.Errorf("SetDefaults() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestGeneratedNoAliasing(t *testing.T) {
+	var a, b generatedTest
+	if err := a.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	if err := b.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	a.Slice[0] = "changed"
+	a.Map["hi"] = "changed"
+	*a.IntPtr = 0
+	if b.Slice[0] != "Hi" || b.Map["hi"] != "hello" || *b.IntPtr != 1_000_000 {
+		t.Errorf("generated defaults share values: %v %v %v", b.Slice, b.Map, *b.IntPtr)
+	}
+}
+
+// Struct with a hand-written Generated implementation, to observe when Apply uses it
+type testGenerated struct {
+	Port int `default:"8080"`
+}
+
+func (x *testGenerated) SetDefaults() error {
+	if x.Port == 0 {
+		x.Port = 1
+	}
+	return nil
+}
+
+func (*testGenerated) DefaultsTag() string {
+	return "default"
+}
+
+func TestApplyPrefersGenerated(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		setup func(t *testing.T)
+		want  int
+	}{
+		{
+			name: "default settings",
+			want: 1,
+		},
+		{
+			name: "other tag key",
+			opts: []Option{WithTag("env")},
+			want: 0,
+		},
+		{
+			name: "overwrite",
+			opts: []Option{WithOverwrite(true)},
+			want: 8080,
+		},
+		{
+			name: "alloc policy",
+			opts: []Option{WithAllocPolicy(AllocNever)},
+			want: 8080,
+		},
+		{
+			name: "hook",
+			opts: []Option{WithHook(func(string, reflect.StructField, reflect.Value) error { return nil })},
+			want: 8080,
+		},
+		{
+			name: "instance parser",
+			opts: []Option{WithParser(reflect.TypeFor[testCelsius](), reflectParser(parseCelsius))},
+			want: 8080,
+		},
+		{
+			name: "registered parser",
+			setup: func(t *testing.T) {
+				RegisterParserFor(parseCelsius)
+				t.Cleanup(func() { UnregisterParser(reflect.TypeFor[testCelsius]()) })
+			},
+			want: 8080,
+		},
+		{
+			name: "built-in parser replaced",
+			setup: func(t *testing.T) {
+				RegisterParserFor(time.ParseDuration)
+				t.Cleanup(func() { RegisterParser(reflect.TypeFor[time.Duration](), ParseDuration) })
+			},
+			want: 8080,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			var got testGenerated
+			if err := New(tt.opts...).Apply(&got); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if got.Port != tt.want {
+				t.Errorf("Port = %d, want %d", got.Port, tt.want)
+			}
+		})
+	}
+
+	var got testGenerated
+	if err := Defaults(&got); err != nil || got.Port != 1 {
+		t.Errorf("Defaults() = %d, %v, want generated method to be used", got.Port, err)
+	}
+}
@@ -0,0 +1,223 @@
+// Code generated by "defaults-gen -type=generatedTest"; DO NOT EDIT.
+
+package defaults
+
+import "time"
+
+// SetDefaults sets the fields of x that are unset to the values of their default tags.
+func (x *generatedTest) SetDefaults() error {
+	setDefaultsGeneratedTest(x)
+	return nil
+}
+
+// DefaultsTag returns the struct tag key SetDefaults was generated from.
+func (*generatedTest) DefaultsTag() string {
+	return "default"
+}
+
+// setDefaultsGeneratedTest sets the unset fields of x to their defaults and reports whether any was set.
+func setDefaultsGeneratedTest(x *generatedTest) (changed bool) {
+	if x.Int == 0 {
+		x.Int = 1000000
+		changed = true
+	}
+	if x.Int8 == 0 {
+		x.Int8 = int8(127)
+		changed = true
+	}
+	if x.Int16 == 0 {
+		x.Int16 = int16(100)
+		changed = true
+	}
+	if x.Int32 == 0 {
+		x.Int32 = int32(1000000)
+		changed = true
+	}
+	if x.Int64 == 0 {
+		x.Int64 = int64(100000000)
+		changed = true
+	}
+	if x.Uint == 0 {
+		x.Uint = uint(100)
+		changed = true
+	}
+	if x.Uint8 == 0 {
+		x.Uint8 = uint8(25)
+		changed = true
+	}
+	if x.Uint16 == 0 {
+		x.Uint16 = uint16(123)
+		changed = true
+	}
+	if x.Uint32 == 0 {
+		x.Uint32 = uint32(321)
+		changed = true
+	}
+	if x.Uint64 == 0 {
+		x.Uint64 = uint64(12312312312)
+		changed = true
+	}
+	if x.Float32 == 0 {
+		x.Float32 = float32(123.3123)
+		changed = true
+	}
+	if x.Float64 == 0 {
+		x.Float64 = 1234.3123
+		changed = true
+	}
+	if x.Complex64 == 0 {
+		x.Complex64 = complex64(complex(123.0, 321.0))
+		changed = true
+	}
+	if x.Complex128 == 0 {
+		x.Complex128 = complex(312.0, 123.0)
+		changed = true
+	}
+	if !x.Bool {
+		x.Bool = true
+		changed = true
+	}
+	if x.String == "" {
+		x.String = "Hello world"
+		changed = true
+	}
+	if x.Map == nil {
+		x.Map = map[string]any{"hello": 3.14, "hi": "hello"}
+		changed = true
+	}
+	if x.Slice == nil {
+		x.Slice = []string{"Hi", "Hi 2", "Hi 3", "Hi 4"}
+		changed = true
+	}
+	if x.Array == ([5]int{}) {
+		x.Array = [5]int{1, 2, 3, 4, 5}
+		changed = true
+	}
+	if x.Duration == 0 {
+		x.Duration = time.Duration(300000000000)
+		changed = true
+	}
+	if setDefaultsGeneratedTestTestStruct(&x.Struct) {
+		changed = true
+	}
+	if x.IntPtr == nil {
+		v := 1000000
+		x.IntPtr = &v
+		changed = true
+	}
+	if x.Int8Ptr == nil {
+		v := int8(127)
+		x.Int8Ptr = &v
+		changed = true
+	}
+	if x.Int16Ptr == nil {
+		v := int16(100)
+		x.Int16Ptr = &v
+		changed = true
+	}
+	if x.Int32Ptr == nil {
+		v := int32(1000000)
+		x.Int32Ptr = &v
+		changed = true
+	}
+	if x.Int64Ptr == nil {
+		v := int64(100000000)
+		x.Int64Ptr = &v
+		changed = true
+	}
+	if x.UintPtr == nil {
+		v := uint(100)
+		x.UintPtr = &v
+		changed = true
+	}
+	if x.Uint8Ptr == nil {
+		v := uint8(25)
+		x.Uint8Ptr = &v
+		changed = true
+	}
+	if x.Uint16Ptr == nil {
+		v := uint16(123)
+		x.Uint16Ptr = &v
+		changed = true
+	}
+	if x.Uint32Ptr == nil {
+		v := uint32(321)
+		x.Uint32Ptr = &v
+		changed = true
+	}
+	if x.Uint64Ptr == nil {
+		v := uint64(12312312312)
+		x.Uint64Ptr = &v
+		changed = true
+	}
+	if x.Float32Ptr == nil {
+		v := float32(123.3123)
+		x.Float32Ptr = &v
+		changed = true
+	}
+	if x.Float64Ptr == nil {
+		v := 1234.3123
+		x.Float64Ptr = &v
+		changed = true
+	}
+	if x.Complex64Ptr == nil {
+		v := complex64(complex(123.0, 321.0))
+		x.Complex64Ptr = &v
+		changed = true
+	}
+	if x.Complex128Ptr == nil {
+		v := complex(312.0, 123.0)
+		x.Complex128Ptr = &v
+		changed = true
+	}
+	if x.BoolPtr == nil {
+		v := true
+		x.BoolPtr = &v
+		changed = true
+	}
+	if x.StringPtr == nil {
+		v := "Hello world"
+		x.StringPtr = &v
+		changed = true
+	}
+	if x.MapPtr == nil {
+		v := map[string]any{"hello": 3.14, "hi": "hello"}
+		x.MapPtr = &v
+		changed = true
+	}
+	if x.SlicePtr == nil {
+		v := []string{"Hi", "Hi 2", "Hi 3", "Hi 4"}
+		x.SlicePtr = &v
+		changed = true
+	}
+	if x.ArrayPtr == nil {
+		v := [5]int{1, 2, 3, 4, 5}
+		x.ArrayPtr = &v
+		changed = true
+	}
+	if x.StructPtr == nil {
+		x.StructPtr = new(testStruct)
+	}
+	if setDefaultsGeneratedTestTestStruct(x.StructPtr) {
+		changed = true
+	}
+	if x.DurationPtr == nil {
+		v := time.Duration(3600000000000)
+		x.DurationPtr = &v
+		changed = true
+	}
+	return changed
+}
+
+// setDefaultsGeneratedTestTestStruct sets the unset fields of x to their defaults and reports whether any was set.
+func setDefaultsGeneratedTestTestStruct(x *testStruct) (changed bool) {
+	if x.A == 0 {
+		x.A = 20112002
+		changed = true
+	}
+	if x.B == "" {
+		x.B = "gm"
+		changed = true
+	}
+	return changed
+}
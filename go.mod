@@ -2,9 +2,14 @@ module github.com/lthphuw/go-defaults
 
 go 1.24.3
 
-require github.com/segmentio/encoding v0.4.1
+require (
+	github.com/segmentio/encoding v0.4.1
+	golang.org/x/tools v0.34.0
+)
 
 require (
 	github.com/segmentio/asm v1.1.3 // indirect
-	golang.org/x/sys v0.0.0-20211110154304-99a53858aa08 // indirect
+	golang.org/x/mod v0.25.0 // indirect
+	golang.org/x/sync v0.15.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 )
@@ -0,0 +1,163 @@
+// Package typeconv converts go/types types into reflect types, so that tools working
+// on source code can parse default tags with the parsers of the defaults package.
+package typeconv
+
+import (
+	"go/types"
+	"reflect"
+	"time"
+	"unsafe"
+
+	defaults "github.com/lthphuw/go-defaults"
+)
+
+// OptPath is the import path of the package declaring Opt and ByteSize.
+const OptPath = "github.com/lthphuw/go-defaults"
+
+var anyType = reflect.TypeFor[any]()
+
+// Converter maps go/types types to reflect types for which Defaults parses
+// default values the same way.
+type Converter struct {
+	skip map[string]bool       // qualified names of types not to convert
+	seen map[*types.Named]bool // named types being converted, to stop on recursive types
+}
+
+// New returns a Converter that does not convert the named types in skip,
+// given by qualified name such as "net.IP" or "example.com/pkg.Celsius".
+func New(skip ...string) *Converter {
+	c := &Converter{skip: make(map[string]bool), seen: make(map[*types.Named]bool)}
+	for _, name := range skip {
+		c.skip[name] = true
+	}
+	return c
+}
+
+// ReflectType returns a reflect.Type that Defaults parses like t, or nil if t cannot
+// be converted because its parsing depends on methods or registered parsers.
+//
+// Named types are converted to their underlying type, except ByteSize, the time
+// types with built-in parsers such as time.Duration and time.Month, and Opt[T],
+// which is converted to T. Elements of composite types that cannot be converted
+// become any, so that the remaining parts of a JSON literal are still parsed.
+func (c *Converter) ReflectType(t types.Type) reflect.Type {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			// Predeclared error type
+			return anyType
+		}
+		qualified := obj.Pkg().Path() + "." + obj.Name()
+		switch {
+		case c.skip[qualified]:
+			return nil
+		case timeTypes[qualified] != nil:
+			return timeTypes[qualified]
+		case qualified == OptPath+".ByteSize":
+			return reflect.TypeFor[defaults.ByteSize]()
+		case qualified == OptPath+".Opt" && t.TypeArgs().Len() == 1:
+			return c.ReflectType(t.TypeArgs().At(0))
+		case HasMethod(t, "UnmarshalText") || HasMethod(t, "UnmarshalJSON"):
+			return nil
+		case c.seen[t]:
+			return nil
+		}
+		c.seen[t] = true
+		defer delete(c.seen, t)
+		return c.ReflectType(t.Underlying())
+	case *types.Basic:
+		return basicTypes[t.Kind()]
+	case *types.Pointer:
+		if elem := c.ReflectType(t.Elem()); elem != nil {
+			return reflect.PointerTo(elem)
+		}
+	case *types.Slice:
+		return reflect.SliceOf(c.elemType(t.Elem()))
+	case *types.Array:
+		return reflect.ArrayOf(int(t.Len()), c.elemType(t.Elem()))
+	case *types.Map:
+		return reflect.MapOf(c.elemType(t.Key()), c.elemType(t.Elem()))
+	case *types.Struct:
+		return c.structType(t)
+	case *types.Interface:
+		return anyType
+	case *types.Chan:
+		return reflect.ChanOf(reflect.BothDir, anyType)
+	case *types.Signature:
+		return reflect.TypeFor[func()]()
+	}
+	return nil
+}
+
+// elemType converts the element type of a composite type. Types that cannot be
+// converted decode any JSON value.
+func (c *Converter) elemType(t types.Type) reflect.Type {
+	if rt := c.ReflectType(t); rt != nil {
+		return rt
+	}
+	return anyType
+}
+
+// structType converts the exported fields of a struct, keeping their tags so that
+// JSON literals decode into the same fields.
+func (c *Converter) structType(t *types.Struct) (rt reflect.Type) {
+	var fields []reflect.StructField
+	for i := range t.NumFields() {
+		field := t.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		fields = append(fields, reflect.StructField{
+			Name:      field.Name(),
+			Type:      c.elemType(field.Type()),
+			Tag:       reflect.StructTag(t.Tag(i)),
+			Anonymous: field.Embedded(),
+		})
+	}
+	// StructOf rejects some embedded fields; such structs are not converted
+	defer func() {
+		if recover() != nil {
+			rt = nil
+		}
+	}()
+	return reflect.StructOf(fields)
+}
+
+// HasMethod reports whether *t has a method with the given name.
+func HasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// timeTypes holds the named types of package time that have built-in parsers,
+// by qualified name. time.Time is left out: its parser depends on the clock and
+// the layout option, so it is not converted.
+var timeTypes = map[string]reflect.Type{
+	"time.Duration": reflect.TypeFor[time.Duration](),
+	"time.Location": reflect.TypeFor[time.Location](),
+	"time.Month":    reflect.TypeFor[time.Month](),
+	"time.Weekday":  reflect.TypeFor[time.Weekday](),
+}
+
+var basicTypes = map[types.BasicKind]reflect.Type{
+	types.Bool:          reflect.TypeFor[bool](),
+	types.Int:           reflect.TypeFor[int](),
+	types.Int8:          reflect.TypeFor[int8](),
+	types.Int16:         reflect.TypeFor[int16](),
+	types.Int32:         reflect.TypeFor[int32](),
+	types.Int64:         reflect.TypeFor[int64](),
+	types.Uint:          reflect.TypeFor[uint](),
+	types.Uint8:         reflect.TypeFor[uint8](),
+	types.Uint16:        reflect.TypeFor[uint16](),
+	types.Uint32:        reflect.TypeFor[uint32](),
+	types.Uint64:        reflect.TypeFor[uint64](),
+	types.Uintptr:       reflect.TypeFor[uintptr](),
+	types.Float32:       reflect.TypeFor[float32](),
+	types.Float64:       reflect.TypeFor[float64](),
+	types.Complex64:     reflect.TypeFor[complex64](),
+	types.Complex128:    reflect.TypeFor[complex128](),
+	types.String:        reflect.TypeFor[string](),
+	types.UnsafePointer: reflect.TypeFor[unsafe.Pointer](),
+}
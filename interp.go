@@ -0,0 +1,152 @@
+package defaults
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LookupFunc returns the value of the variable name and whether it is set,
+// like os.LookupEnv.
+type LookupFunc func(name string) (value string, ok bool)
+
+// MapLookup returns a LookupFunc reading variables from m instead of the
+// environment, as used in tests.
+func MapLookup(m map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := m[name]
+		return v, ok
+	}
+}
+
+// SetLookup sets the function used by Defaults to resolve ${VAR} references in
+// default tags. A nil fn restores os.LookupEnv. Use WithLookup to configure a
+// Defaulter instead.
+func SetLookup(fn LookupFunc) {
+	std.env = fn
+}
+
+// SetInterpolation enables or disables the expansion of ${VAR} references in
+// default tags by Defaults. It is enabled by default. Use WithInterpolation to
+// configure a Defaulter instead.
+func SetInterpolation(enabled bool) {
+	std.noInterp = !enabled
+}
+
+// hasReferences reports whether a tag value contains ${VAR} references or $$ escapes.
+func hasReferences(value string) bool {
+	return strings.Contains(value, "${") || strings.Contains(value, "$$")
+}
+
+// interpolator expands variable references in tag values:
+//
+//	${VAR}           value of VAR, or "" if it is not set
+//	${VAR:-fallback} value of VAR, or fallback if it is not set or empty
+//	${.Field}        value of a field, resolved by ref (see refs.go)
+//	$$               a literal $
+//
+// Fallbacks may contain references themselves. A $ followed by anything
+// else is kept as is.
+type interpolator struct {
+	lookup  LookupFunc
+	ref     func(path string) (string, bool, error) // nil if field references are not allowed
+	missing bool                                    // a variable without fallback was not set
+}
+
+// interpolator returns the interpolator for the Defaulter's lookup function.
+func (d *Defaulter) interpolator() *interpolator {
+	lookup := d.env
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	return &interpolator{lookup: lookup}
+}
+
+// expand returns s with its references expanded.
+func (in *interpolator) expand(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i += 2
+		case '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated variable reference in %q", ErrSyntax, s)
+			}
+			name, fallback, hasFallback := strings.Cut(s[i+2:end], ":-")
+			var val string
+			var ok bool
+			switch {
+			case isRefPath(name):
+				if in.ref == nil {
+					return "", fmt.Errorf("%w: field reference ${%s} is not allowed here", ErrReference, name)
+				}
+				var err error
+				if val, ok, err = in.ref(name); err != nil && !(hasFallback && errors.Is(err, errNilRef)) {
+					return "", err
+				}
+			case isVarName(name):
+				val, ok = in.lookup(name)
+			default:
+				return "", fmt.Errorf("%w: invalid variable name %q in %q", ErrSyntax, name, s)
+			}
+			switch {
+			case hasFallback && (val == "" || !ok):
+				var err error
+				if val, err = in.expand(fallback); err != nil {
+					return "", err
+				}
+			case !ok:
+				in.missing = true
+			}
+			b.WriteString(val)
+			i = end + 1
+		default:
+			b.WriteByte('$')
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the brace closing a reference whose
+// name starts at s[start], skipping nested references, or -1.
+func closingBrace(s string, start int) int {
+	depth := 1
+	for j := start; j < len(s); j++ {
+		switch {
+		case s[j] == '$' && j+1 < len(s) && (s[j+1] == '{' || s[j+1] == '$'):
+			if s[j+1] == '{' {
+				depth++
+			}
+			j++
+		case s[j] == '}':
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// isVarName reports whether name is a valid variable name: letters,
+// digits and underscores, not starting with a digit.
+func isVarName(name string) bool {
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
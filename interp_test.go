@@ -0,0 +1,146 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"HOME": "/home/app", "PORT": "9090", "EMPTY": ""}
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantMissing bool
+		wantErr     bool
+	}{
+		{name: "no references", input: "plain", want: "plain"},
+		{name: "variable", input: "${HOME}/data", want: "/home/app/data"},
+		{name: "several variables", input: "${HOME}:${PORT}", want: "/home/app:9090"},
+		{name: "unset variable", input: "a${NOPE}b", want: "ab", wantMissing: true},
+		{name: "fallback unused", input: "${PORT:-8080}", want: "9090"},
+		{name: "fallback for unset", input: "${NOPE:-8080}", want: "8080"},
+		{name: "fallback for empty", input: "${EMPTY:-8080}", want: "8080"},
+		{name: "nested fallback", input: "${NOPE:-${HOME}/x}", want: "/home/app/x"},
+		{name: "escaped dollar", input: "$${HOME}", want: "${HOME}"},
+		{name: "lone dollar", input: "^a$ $x", want: "^a$ $x"},
+		{name: "escape in fallback", input: "${NOPE:-$$}", want: "$"},
+		{name: "unterminated", input: "${HOME", wantErr: true},
+		{name: "empty name", input: "${}", wantErr: true},
+		{name: "invalid name", input: "${1A}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &interpolator{lookup: MapLookup(env)}
+			got, err := in.expand(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrSyntax) {
+					t.Errorf("expand() error = %v, want ErrSyntax", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("expand() = %q, want %q", got, tt.want)
+			}
+			if in.missing != tt.wantMissing {
+				t.Errorf("missing = %v, want %v", in.missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+type testInterp struct {
+	Dir     string   `default:"${APP_HOME}/data"`
+	Port    int      `default:"${PORT:-8080}"`
+	Hosts   []string `default:"[\"${HOST:-localhost}\"]"`
+	Price   string   `default:"$$5"`
+	Literal string   `default:"a$b"`
+}
+
+func TestApplyInterpolation(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		want      *testInterp
+		errString string
+	}{
+		{
+			name: "lookup",
+			opts: []Option{WithLookup(MapLookup(map[string]string{"APP_HOME": "/srv", "PORT": "9090"}))},
+			want: &testInterp{Dir: "/srv/data", Port: 9090, Hosts: []string{"localhost"}, Price: "$5", Literal: "a$b"},
+		},
+		{
+			name: "fallbacks",
+			opts: []Option{WithLookup(MapLookup(nil))},
+			want: &testInterp{Dir: "/data", Port: 8080, Hosts: []string{"localhost"}, Price: "$5", Literal: "a$b"},
+		},
+		{
+			name:      "disabled",
+			opts:      []Option{WithInterpolation(false)},
+			want:      &testInterp{Dir: "${APP_HOME}/data"},
+			errString: `failed to set default for field Port: strconv.ParseInt: parsing "${PORT:-8080}": invalid syntax`,
+		},
+		{
+			name:      "invalid value",
+			opts:      []Option{WithLookup(MapLookup(map[string]string{"PORT": "http"}))},
+			want:      &testInterp{Dir: "/data"},
+			errString: `failed to set default for field Port: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &testInterp{}
+			err := New(tt.opts...).Apply(got)
+			if tt.errString != "" {
+				if err == nil || err.Error() != tt.errString {
+					t.Errorf("Apply() error = %v, want %q", err, tt.errString)
+				}
+			} else if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolationAtApplyTime(t *testing.T) {
+	env := map[string]string{}
+	d := New(WithLookup(MapLookup(env)))
+	for _, want := range []int{1, 2} {
+		env["PORT"] = strconv.Itoa(want)
+		got := &testInterp{}
+		if err := d.Apply(got); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		if got.Port != want {
+			t.Errorf("Port = %d, want %d", got.Port, want)
+		}
+	}
+}
+
+func TestSetLookup(t *testing.T) {
+	t.Cleanup(func() {
+		SetLookup(nil)
+		SetInterpolation(true)
+	})
+	SetLookup(MapLookup(map[string]string{"APP_HOME": "/opt"}))
+	got := &testInterp{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if got.Dir != "/opt/data" {
+		t.Errorf("Dir = %q, want %q", got.Dir, "/opt/data")
+	}
+
+	SetInterpolation(false)
+	if err := Defaults(&testInterp{}); err == nil {
+		t.Error("Defaults() error = nil, want parse error with interpolation disabled")
+	}
+}
@@ -0,0 +1,58 @@
+package defaults
+
+// Make returns a zero T with its defaults applied, so that constructors reduce to
+//
+//	func NewConfig() (Config, error) { return defaults.Make[Config]() }
+//
+// The constructors are named Make rather than New, which creates a Defaulter.
+//
+// Without options Make uses the package-level settings, like Defaults. With options
+// it uses a Defaulter created by New(opts...), which does not reuse cached plans
+// across calls; prefer a shared Defaulter's Apply in hot paths.
+//
+// T must be a struct type. On failure Make returns the zero T and the error.
+func Make[T any](opts ...Option) (T, error) {
+	p, err := MakePtr[T](opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return *p, nil
+}
+
+// MakePtr is like Make but returns a pointer to the defaulted T, or nil on failure.
+func MakePtr[T any](opts ...Option) (*T, error) {
+	d := std
+	if len(opts) > 0 {
+		d = New(opts...)
+	}
+	p := new(T)
+	if err := d.Apply(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MustMake is like Make but panics if the defaults cannot be applied.
+// It simplifies package-level variable initialization:
+//
+//	var defaultConfig = defaults.MustMake[Config]()
+func MustMake[T any](opts ...Option) T {
+	v, err := Make[T](opts...)
+	if err != nil {
+		panic("defaults: MustMake: " + err.Error())
+	}
+	return v
+}
+
+// MakeOr is like Make but returns fallback if the defaults cannot be applied.
+// It initializes package-level variables without panicking:
+//
+//	var defaultConfig = defaults.MakeOr(Config{Port: 8080})
+func MakeOr[T any](fallback T, opts ...Option) T {
+	v, err := Make[T](opts...)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testMake struct {
+	Name  string `default:"app"`
+	Port  int    `default:"8080"`
+	Alt   string `alt:"alt"`
+	Inner *testStruct
+}
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    testMake
+		wantErr error
+	}{
+		{
+			name: "package settings",
+			want: testMake{Name: "app", Port: 8080, Inner: &testStruct{A: 20112002, B: "gm"}},
+		},
+		{
+			name: "with options",
+			opts: []Option{WithTag("alt"), WithAllocPolicy(AllocNever)},
+			want: testMake{Alt: "alt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Make[testMake](tt.opts...)
+			if err != nil {
+				t.Fatalf("Make() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Make() = %+v, want %+v", got, tt.want)
+			}
+
+			ptr, err := MakePtr[testMake](tt.opts...)
+			if err != nil {
+				t.Fatalf("MakePtr() error = %v", err)
+			}
+			if !reflect.DeepEqual(*ptr, tt.want) {
+				t.Errorf("MakePtr() = %+v, want %+v", *ptr, tt.want)
+			}
+
+			if got := MustMake[testMake](tt.opts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MustMake() = %+v, want %+v", got, tt.want)
+			}
+
+			if got := MakeOr(testMake{Name: "fallback"}, tt.opts...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeOr() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeErrors(t *testing.T) {
+	got, err := Make[testInt8Overflow]()
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Make() error = %v, want ErrOutOfRange", err)
+	}
+	if got != (testInt8Overflow{}) {
+		t.Errorf("Make() = %+v, want zero value", got)
+	}
+
+	if ptr, err := MakePtr[int](); ptr != nil || !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("MakePtr[int]() = %v, %v, want nil, ErrInvalidInput", ptr, err)
+	}
+
+	fallback := testInt8Overflow{Int8: 1}
+	if got := MakeOr(fallback); got != fallback {
+		t.Errorf("MakeOr() = %+v, want %+v", got, fallback)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustMake() did not panic")
+		}
+	}()
+	MustMake[testInt8Overflow]()
+}
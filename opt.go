@@ -0,0 +1,105 @@
+package defaults
+
+import (
+	"reflect"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// Presence is implemented by field types that track whether a value was provided.
+// Defaults treats such a field as unset only when IsSet reports false, so that
+// false, 0 or "" can be intentional values.
+type Presence interface {
+	IsSet() bool
+}
+
+// Opt holds an optional value of type T together with whether it was set.
+// Defaults fills an Opt field from its tag only if the Opt is not set:
+//
+//	type Config struct {
+//		Debug defaults.Opt[bool] `default:"true"`
+//	}
+//
+// Opt marshals to JSON as its value, or null when unset, and an Opt decoded
+// from JSON is set unless the JSON value is null.
+type Opt[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an Opt that is set to v.
+func Some[T any](v T) Opt[T] {
+	return Opt[T]{value: v, set: true}
+}
+
+// Get returns the value and whether it was set.
+func (o Opt[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// Value returns the value, or the zero value of T if it was not set.
+func (o Opt[T]) Value() T {
+	return o.value
+}
+
+// IsSet reports whether the value was set.
+func (o Opt[T]) IsSet() bool {
+	return o.set
+}
+
+// Set sets the value to v and marks it as set.
+func (o *Opt[T]) Set(v T) {
+	o.value, o.set = v, true
+}
+
+// Unset clears the value and marks it as not set.
+func (o *Opt[T]) Unset() {
+	*o = Opt[T]{}
+}
+
+// MarshalJSON encodes the value, or null if it was not set.
+func (o Opt[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes the value and marks it as set. A JSON null leaves it unset.
+func (o *Opt[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		o.Unset()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Set(v)
+	return nil
+}
+
+// valueType returns the type of the wrapped value.
+func (o *Opt[T]) valueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// setValue sets the wrapped value from a parsed default.
+func (o *Opt[T]) setValue(v reflect.Value) {
+	o.Set(v.Interface().(T))
+}
+
+// wrapped returns the wrapped value.
+func (o *Opt[T]) wrapped() reflect.Value {
+	return reflect.ValueOf(&o.value).Elem()
+}
+
+// optional is implemented by *Opt so that Defaults can parse, set and copy the wrapped value.
+type optional interface {
+	Presence
+	valueType() reflect.Type
+	setValue(v reflect.Value)
+	wrapped() reflect.Value
+}
+
+var optionalType = reflect.TypeFor[optional]()
@@ -0,0 +1,169 @@
+package defaults
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// testFlag implements Presence without being an Opt
+type testFlag struct {
+	On       bool
+	Provided bool
+}
+
+func (f testFlag) IsSet() bool {
+	return f.Provided
+}
+
+// Struct for testing optional fields
+type testOptional struct {
+	Bool     Opt[bool]          `default:"true"`
+	Int      Opt[int]           `default:"42"`
+	String   Opt[string]        `default:"hello"`
+	Duration Opt[time.Duration] `default:"5s"`
+	Slice    Opt[[]string]      `default:"[\"a\",\"b\"]"`
+	IntPtr   *Opt[int]          `default:"7"`
+	Untagged Opt[int]
+	Flag     testFlag `default:"{\"On\":true,\"Provided\":true}"`
+}
+
+func TestOptDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		want      any
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "unset values receive defaults",
+			input: &testOptional{},
+			want: &testOptional{
+				Bool:     Some(true),
+				Int:      Some(42),
+				String:   Some("hello"),
+				Duration: Some(5 * time.Second),
+				Slice:    Some([]string{"a", "b"}),
+				IntPtr:   &Opt[int]{value: 7, set: true},
+				Flag:     testFlag{On: true, Provided: true},
+			},
+		},
+		{
+			name: "explicit zero values are kept",
+			input: &testOptional{
+				Bool:     Some(false),
+				Int:      Some(0),
+				String:   Some(""),
+				Duration: Some(time.Duration(0)),
+				Slice:    Some([]string(nil)),
+				IntPtr:   &Opt[int]{},
+				Flag:     testFlag{Provided: true},
+			},
+			want: &testOptional{
+				Bool:     Some(false),
+				Int:      Some(0),
+				String:   Some(""),
+				Duration: Some(time.Duration(0)),
+				Slice:    Some([]string(nil)),
+				IntPtr:   &Opt[int]{},
+				Flag:     testFlag{Provided: true},
+			},
+		},
+		{
+			name: "invalid default",
+			input: &struct {
+				Int Opt[int] `default:"abc"`
+			}{},
+			want: &struct {
+				Int Opt[int] `default:"abc"`
+			}{},
+			wantErr:   true,
+			errString: "invalid syntax",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDefaults() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errString) {
+				t.Errorf("SetDefaults() error = %v, expected to contain %q", err, tt.errString)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("SetDefaults() input = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpt(t *testing.T) {
+	var o Opt[int]
+	if v, ok := o.Get(); v != 0 || ok {
+		t.Errorf("Get() = %v, %v, want 0, false", v, ok)
+	}
+	o.Set(0)
+	if !o.IsSet() || o.Value() != 0 {
+		t.Errorf("after Set(0): IsSet() = %v, Value() = %v, want true, 0", o.IsSet(), o.Value())
+	}
+	o.Unset()
+	if o.IsSet() {
+		t.Errorf("after Unset(): IsSet() = true, want false")
+	}
+}
+
+func TestOptJSON(t *testing.T) {
+	type config struct {
+		Debug Opt[bool] `json:"debug"`
+		Port  Opt[int]  `json:"port"`
+		Name  Opt[string]
+	}
+	tests := []struct {
+		name     string
+		input    string
+		want     config
+		wantJSON string
+	}{
+		{
+			name:     "explicit false and zero",
+			input:    `{"debug":false,"port":0}`,
+			want:     config{Debug: Some(false), Port: Some(0)},
+			wantJSON: `{"debug":false,"port":0,"Name":null}`,
+		},
+		{
+			name:     "null is unset",
+			input:    `{"debug":null,"Name":"x"}`,
+			want:     config{Name: Some("x")},
+			wantJSON: `{"debug":null,"port":null,"Name":"x"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got config
+			if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("Marshal() = %s, want %s", data, tt.wantJSON)
+			}
+		})
+	}
+
+	var bad Opt[int]
+	if err := json.Unmarshal([]byte(`"x"`), &bad); err == nil {
+		t.Errorf("Unmarshal() of invalid value error = nil, want error")
+	}
+}
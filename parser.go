@@ -1,11 +1,11 @@
 package defaults
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
-	"time"
 
 	"github.com/segmentio/encoding/json"
 )
@@ -15,31 +15,9 @@ var ErrUnsupportedType = errors.New("unsupported type")
 
 // ParserFunc defines a function type for parsing a string into a reflect.Value
 // based on the specified reflect.Type, returning the parsed value and any error encountered.
+// The returned value must be assignable to t. Custom parsers are installed with RegisterParser.
 type ParserFunc func(str string, t reflect.Type) (reflect.Value, error)
 
-var parser = map[string]ParserFunc{
-	reflect.Int.String():                      ParseInt,
-	reflect.Int8.String():                     ParseInt,
-	reflect.Int16.String():                    ParseInt,
-	reflect.Int32.String():                    ParseInt,
-	reflect.Int64.String():                    ParseInt,
-	reflect.Uint.String():                     ParseUint,
-	reflect.Uint8.String():                    ParseUint,
-	reflect.Uint16.String():                   ParseUint,
-	reflect.Uint32.String():                   ParseUint,
-	reflect.Uint64.String():                   ParseUint,
-	reflect.Float32.String():                  ParseFloat,
-	reflect.Float64.String():                  ParseFloat,
-	reflect.Complex64.String():                ParseComplex,
-	reflect.Complex128.String():               ParseComplex,
-	reflect.Bool.String():                     ParseBool,
-	reflect.String.String():                   ParseString,
-	reflect.TypeOf(time.Duration(0)).String(): ParseDuration,
-	reflect.Map.String():                      ParseMap,
-	reflect.Slice.String():                    ParseSlice,
-	reflect.Array.String():                    ParseArray,
-}
-
 // ParseInt parses a string to an integer type (int, int8, int16, int32, int64).
 func ParseInt(str string, t reflect.Type) (reflect.Value, error) {
 	var bitSize int
@@ -134,22 +112,61 @@ func ParseString(str string, t reflect.Type) (reflect.Value, error) {
 	return reflect.ValueOf(str).Convert(t), nil
 }
 
-// ParseDuration parses a string to a time.Duration.
+// ParseDuration parses a string to a time.Duration. It accepts the syntax of
+// time.ParseDuration, such as 1h30m, extended with the d (24h) and w (7d) units,
+// as in 7d or 1w2d12h, and ISO 8601 durations such as P7D, P1W or PT1H30M.
+// ISO 8601 years and months are rejected, as they have no fixed length.
 func ParseDuration(str string, t reflect.Type) (reflect.Value, error) {
-	val, err := time.ParseDuration(str)
+	val, err := parseDuration(str)
 	if err != nil {
 		return reflect.Value{}, err
 	}
 	return reflect.ValueOf(val).Convert(t), nil
 }
 
+// ParseText parses a string to a type implementing encoding.TextUnmarshaler,
+// either on the value itself or on its pointer.
+func ParseText(str string, t reflect.Type) (reflect.Value, error) {
+	val := reflect.New(t)
+	unmarshaler, ok := val.Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("t does not implement encoding.TextUnmarshaler")
+	}
+	if err := unmarshaler.UnmarshalText([]byte(str)); err != nil {
+		return reflect.Value{}, err
+	}
+	return val.Elem(), nil
+}
+
+// ParseJSON parses a JSON string to any type using the JSON decoder,
+// honoring json.Unmarshaler implementations.
+func ParseJSON(str string, t reflect.Type) (reflect.Value, error) {
+	val := reflect.New(t)
+	if err := json.Unmarshal([]byte(str), val.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid JSON format: %w", err)
+	}
+	return val.Elem(), nil
+}
+
+// ParseStruct parses a JSON object string to a struct.
+func ParseStruct(str string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("t is not a struct")
+	}
+	val := reflect.New(t)
+	if err := unmarshalJSON(str, val); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid struct format: %w", err)
+	}
+	return val.Elem(), nil
+}
+
 // ParseMap parses a JSON-like string to a map.
 func ParseMap(str string, t reflect.Type) (reflect.Value, error) {
 	if t.Kind() != reflect.Map {
 		return reflect.Value{}, fmt.Errorf("t is not a map")
 	}
 	val := reflect.New(t)
-	if err := json.Unmarshal([]byte(str), val.Interface()); err != nil {
+	if err := unmarshalJSON(str, val); err != nil {
 		return reflect.Value{}, fmt.Errorf("invalid map format: %w", err)
 	}
 	return val.Elem(), nil
@@ -161,7 +178,7 @@ func ParseSlice(str string, t reflect.Type) (reflect.Value, error) {
 		return reflect.Value{}, fmt.Errorf("t is not a slice")
 	}
 	val := reflect.New(t)
-	if err := json.Unmarshal([]byte(str), val.Interface()); err != nil {
+	if err := unmarshalJSON(str, val); err != nil {
 		return reflect.Value{}, fmt.Errorf("invalid slice format: %w", err)
 	}
 	return val.Elem(), nil
@@ -175,7 +192,7 @@ func ParseArray(str string, t reflect.Type) (reflect.Value, error) {
 	// Parse into a slice first
 	elemType := t.Elem()
 	tempSlicePtr := reflect.New(reflect.SliceOf(elemType))
-	if err := json.Unmarshal([]byte(str), tempSlicePtr.Interface()); err != nil {
+	if err := unmarshalJSON(str, tempSlicePtr); err != nil {
 		return reflect.Value{}, fmt.Errorf("invalid array format: %w", err)
 	}
 
@@ -184,7 +201,8 @@ func ParseArray(str string, t reflect.Type) (reflect.Value, error) {
 	expectedLen := t.Len()
 	if tempSlice.Len() > expectedLen {
 		return reflect.Value{}, fmt.Errorf(
-			"array length %d exceeds capacity %d",
+			"%w: array length %d exceeds capacity %d",
+			ErrOutOfRange,
 			tempSlice.Len(),
 			expectedLen,
 		)
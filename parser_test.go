@@ -3,7 +3,9 @@ package defaults
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
+	"net/netip"
 	"reflect"
 	"strconv"
 	"strings"
@@ -1254,6 +1256,27 @@ func TestParseDuration(t *testing.T) {
 			want:    reflect.ValueOf(45 * time.Second),
 			wantErr: false,
 		},
+		{
+			name:  "days and weeks",
+			input: "1w2d12h",
+			want:  reflect.ValueOf(9*24*time.Hour + 12*time.Hour),
+		},
+		{
+			name:  "negative fractional days",
+			input: "-1.5d",
+			want:  reflect.ValueOf(-36 * time.Hour),
+		},
+		{
+			name:  "ISO 8601",
+			input: "PT1H30M",
+			want:  reflect.ValueOf(90 * time.Minute),
+		},
+		{
+			name:      "ISO 8601 months",
+			input:     "P1M",
+			wantErr:   true,
+			errString: "have no fixed duration",
+		},
 		{
 			name:      "invalid duration",
 			input:     "1x",
@@ -1294,6 +1317,199 @@ func TestParseDuration(t *testing.T) {
 	}
 }
 
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		typ       reflect.Type
+		want      reflect.Value
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "netip address",
+			input: "192.168.1.1",
+			typ:   reflect.TypeOf(netip.Addr{}),
+			want:  reflect.ValueOf(netip.MustParseAddr("192.168.1.1")),
+		},
+		{
+			name:  "slog level",
+			input: "WARN",
+			typ:   reflect.TypeOf(slog.Level(0)),
+			want:  reflect.ValueOf(slog.LevelWarn),
+		},
+		{
+			name:      "invalid netip address",
+			input:     "not an ip",
+			typ:       reflect.TypeOf(netip.Addr{}),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "ParseAddr",
+		},
+		{
+			name:      "not a text unmarshaler",
+			input:     "1",
+			typ:       reflect.TypeOf(int(0)),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "does not implement encoding.TextUnmarshaler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseText(tt.input, tt.typ)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseText() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err != nil && tt.errString != "" && !contains(err.Error(), tt.errString) {
+				t.Errorf("ParseText() error = %v, expected to contain %q", err, tt.errString)
+			}
+
+			if got.IsValid() != tt.want.IsValid() {
+				t.Errorf("ParseText() = %v, want %v", got, tt.want)
+			}
+			if got.IsValid() && tt.want.IsValid() &&
+				!reflect.DeepEqual(got.Interface(), tt.want.Interface()) {
+				t.Errorf("ParseText() = %v, want %v", got.Interface(), tt.want.Interface())
+			}
+		})
+	}
+}
+
+func TestParseStruct(t *testing.T) {
+	type point struct {
+		X int
+		Y int `json:"y"`
+	}
+	tests := []struct {
+		name      string
+		input     string
+		typ       reflect.Type
+		want      reflect.Value
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "valid struct",
+			input: `{"X":1,"y":2}`,
+			typ:   reflect.TypeOf(point{}),
+			want:  reflect.ValueOf(point{X: 1, Y: 2}),
+		},
+		{
+			name:  "partial struct",
+			input: `{"y":2}`,
+			typ:   reflect.TypeOf(point{}),
+			want:  reflect.ValueOf(point{Y: 2}),
+		},
+		{
+			name:      "invalid JSON",
+			input:     `{X:1}`,
+			typ:       reflect.TypeOf(point{}),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "invalid struct format",
+		},
+		{
+			name:      "not a struct",
+			input:     `{}`,
+			typ:       reflect.TypeOf(0),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "t is not a struct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStruct(tt.input, tt.typ)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStruct() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err != nil && tt.errString != "" && !contains(err.Error(), tt.errString) {
+				t.Errorf("ParseStruct() error = %v, expected to contain %q", err, tt.errString)
+			}
+
+			if got.IsValid() != tt.want.IsValid() {
+				t.Errorf("ParseStruct() = %v, want %v", got, tt.want)
+			}
+			if got.IsValid() && tt.want.IsValid() &&
+				!reflect.DeepEqual(got.Interface(), tt.want.Interface()) {
+				t.Errorf("ParseStruct() = %v, want %v", got.Interface(), tt.want.Interface())
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		typ       reflect.Type
+		want      reflect.Value
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "json unmarshaler",
+			input: `"a|b"`,
+			typ:   reflect.TypeOf(testPair{}),
+			want:  reflect.ValueOf(testPair{Left: "a", Right: "b"}),
+		},
+		{
+			name:  "plain value",
+			input: `[1,2]`,
+			typ:   reflect.TypeOf([]int{}),
+			want:  reflect.ValueOf([]int{1, 2}),
+		},
+		{
+			name:      "unmarshaler error",
+			input:     `"ab"`,
+			typ:       reflect.TypeOf(testPair{}),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "missing separator",
+		},
+		{
+			name:      "invalid JSON",
+			input:     `a|b`,
+			typ:       reflect.TypeOf(testPair{}),
+			want:      reflect.Value{},
+			wantErr:   true,
+			errString: "invalid JSON format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON(tt.input, tt.typ)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err != nil && tt.errString != "" && !contains(err.Error(), tt.errString) {
+				t.Errorf("ParseJSON() error = %v, expected to contain %q", err, tt.errString)
+			}
+
+			if got.IsValid() != tt.want.IsValid() {
+				t.Errorf("ParseJSON() = %v, want %v", got, tt.want)
+			}
+			if got.IsValid() && tt.want.IsValid() &&
+				!reflect.DeepEqual(got.Interface(), tt.want.Interface()) {
+				t.Errorf("ParseJSON() = %v, want %v", got.Interface(), tt.want.Interface())
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
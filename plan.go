@@ -0,0 +1,226 @@
+package defaults
+
+import (
+	"reflect"
+)
+
+// structPlan is the compiled form of a struct type for a given tag key: the
+// exported fields with their tag values and options, and the pre-parsed
+// defaults. Plans are cached per Defaulter and are immutable once compiled.
+type structPlan struct {
+	gen    uint64 // registry generation the plan was compiled against
+	fields []*fieldPlan
+	setter setterKind // SetDefaults method to call after the fields
+}
+
+// fieldPlan is the compiled form of a single exported struct field.
+type fieldPlan struct {
+	index     int
+	field     reflect.StructField
+	value     string       // default value from the tag, without options
+	refs      bool         // value holds ${VAR} references or $$ escapes
+	fieldRefs bool         // value holds ${.Field} references
+	vopts     valueOptions // options for parsing value
+	opts      tagOptions   // options from the tag
+	tagErr    error        // error from parsing the tag options
+	nested    bool         // struct or struct pointer to recurse into
+	elems     bool         // slice, array or map of structs or struct pointers to recurse into
+	unset     unsetCheck   // how to test the field for being unset
+
+	// parsed reports whether def and parseErr hold the result of parsing value
+	// at compile time. Values that cannot be safely copied are parsed on every use.
+	parsed   bool
+	def      reflect.Value
+	parseErr error
+}
+
+// planKey identifies a cached plan.
+type planKey struct {
+	typ      reflect.Type
+	tag      string
+	syntax   Syntax
+	quantity bool
+}
+
+// plan returns the compiled plan for struct type t and tag key tag, compiling and
+// caching it on first use. Plans compiled before a parser was registered or
+// unregistered are recompiled.
+func (d *Defaulter) plan(t reflect.Type, tag string) *structPlan {
+	key := planKey{typ: t, tag: tag, syntax: d.syntax, quantity: d.quantity}
+	gen := parsers.generation()
+	if p, ok := d.plans.Load(key); ok && p.(*structPlan).gen == gen {
+		return p.(*structPlan)
+	}
+	p := d.compile(t, tag, gen)
+	d.plans.Store(key, p)
+	return p
+}
+
+// compile builds the plan for struct type t.
+func (d *Defaulter) compile(t reflect.Type, tag string, gen uint64) *structPlan {
+	p := &structPlan{gen: gen, setter: setterKindFor(t)}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fp := &fieldPlan{
+			index: i,
+			field: field,
+			unset: unsetCheckFor(field.Type),
+		}
+		fp.value, fp.opts, fp.tagErr = parseTag(field.Tag.Get(tag), field.Type)
+		tagged := fp.value != ""
+		fp.nested = isStructOrStructPtr(field.Type) && !d.parsedWhole(field.Type, tagged)
+		fp.elems = d.hasStructElems(field.Type, tagged)
+		fp.refs = hasReferences(fp.value)
+		fp.fieldRefs = hasFieldRefs(fp.value)
+		fp.vopts = d.valueOptions(fp.opts)
+		if fp.tagErr == nil && fp.value != "" && !isProviderCall(fp.value) && d.copyable(field.Type) {
+			fp.def, fp.parseErr = d.parseDefault(field.Type, fp.value, fp.value, fp.vopts)
+			fp.parsed = true
+		}
+		p.fields = append(p.fields, fp)
+	}
+	return p
+}
+
+// defaultValue returns a fresh copy of the field's default value, parsing it now
+// if it could not be parsed at compile time, holds references to expand or calls a provider.
+func (d *Defaulter) defaultValue(fp *fieldPlan) (reflect.Value, error) {
+	if fp.refs && !d.noInterp {
+		value, err := d.interpolator().expand(fp.value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return d.parseDefault(fp.field.Type, fp.value, value, fp.vopts)
+	}
+	if !fp.parsed {
+		return d.parseDefault(fp.field.Type, fp.value, fp.value, fp.vopts)
+	}
+	if fp.parseErr != nil {
+		return reflect.Value{}, fp.parseErr
+	}
+	return deepCopy(fp.def), nil
+}
+
+// copyable reports whether values of type t can be deep-copied with deepCopy, so
+// that a default parsed once can be handed out without callers aliasing each other.
+// Structs with unexported fields cannot be copied through reflection, and values
+// from registered parsers may rely on pointer identity, so only their scalars are copied.
+func (d *Defaulter) copyable(t reflect.Type) bool {
+	return d.copyableSeen(t, make(map[reflect.Type]bool))
+}
+
+func (d *Defaulter) copyableSeen(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+
+	if d.hasType(t) {
+		return isScalar(t)
+	}
+	if reflect.PointerTo(t).Implements(optionalType) {
+		return d.copyableSeen(reflect.New(t).Interface().(optional).valueType(), seen)
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		// Interface values produced by the built-in parsers are decoded JSON data
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return d.copyableSeen(t.Elem(), seen)
+	case reflect.Map:
+		return d.copyableSeen(t.Key(), seen) && d.copyableSeen(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if !t.Field(i).IsExported() || !d.copyableSeen(t.Field(i).Type, seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return isScalar(t)
+	}
+}
+
+// isScalar reports whether t is a boolean, numeric or string type.
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	default:
+		return false
+	}
+}
+
+// deepCopy returns a copy of v that shares no maps, slices or pointers with it.
+func deepCopy(v reflect.Value) reflect.Value {
+	t := v.Type()
+	if reflect.PointerTo(t).Implements(optionalType) {
+		src := reflect.New(t)
+		src.Elem().Set(v)
+		dst := reflect.New(t)
+		if src.Interface().(optional).IsSet() {
+			dst.Interface().(optional).setValue(deepCopy(src.Interface().(optional).wrapped()))
+		}
+		return dst.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		dst := reflect.New(t.Elem())
+		dst.Elem().Set(deepCopy(v.Elem()))
+		return dst
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		dst := reflect.New(t).Elem()
+		dst.Set(deepCopy(v.Elem()))
+		return dst
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		dst := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := range v.Len() {
+			dst.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return dst
+	case reflect.Array:
+		dst := reflect.New(t).Elem()
+		for i := range v.Len() {
+			dst.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return dst
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		dst := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return dst
+	case reflect.Struct:
+		dst := reflect.New(t).Elem()
+		dst.Set(v)
+		for i := range v.NumField() {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return dst
+	default:
+		return v
+	}
+}
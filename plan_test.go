@@ -0,0 +1,186 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanCache(t *testing.T) {
+	d := New()
+	var first test
+	if err := d.Apply(&first); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	p := d.plan(reflect.TypeFor[test](), defaultTag)
+	if p != d.plan(reflect.TypeFor[test](), defaultTag) {
+		t.Errorf("plan() compiled the same type twice")
+	}
+	if p == d.plan(reflect.TypeFor[test](), "env") {
+		t.Errorf("plan() shared a plan between tag keys")
+	}
+
+	// Defaults handed out by a cached plan must not alias each other
+	first.Map["hi"] = "changed"
+	first.Slice[0] = "changed"
+	*first.IntPtr = 1
+	(*first.MapPtr)["hi"] = "changed"
+
+	var second test
+	if err := d.Apply(&second); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if second.Map["hi"] != "hello" || second.Slice[0] != "Hi" || *second.IntPtr != 1_000_000 ||
+		(*second.MapPtr)["hi"] != "hello" {
+		t.Errorf("Apply() returned aliased defaults: %v %v %v %v",
+			second.Map, second.Slice, *second.IntPtr, *second.MapPtr)
+	}
+}
+
+func TestPlanRegistryChange(t *testing.T) {
+	type celsius struct {
+		Celsius testCelsius `default:"21.5C"`
+	}
+	d := New()
+	if err := d.Apply(&celsius{}); err == nil {
+		t.Fatalf("Apply() error = nil, want error for the unregistered parser")
+	}
+
+	RegisterParserFor(parseCelsius)
+	t.Cleanup(func() { UnregisterParser(reflect.TypeFor[testCelsius]()) })
+
+	var got celsius
+	if err := d.Apply(&got); err != nil {
+		t.Fatalf("Apply() after RegisterParser error = %v", err)
+	}
+	if got.Celsius.Degrees != 21.5 {
+		t.Errorf("Apply() Celsius = %v, want 21.5", got.Celsius.Degrees)
+	}
+}
+
+func TestPlanParseErrorOnlyWhenUnset(t *testing.T) {
+	type invalid struct {
+		Int int `default:"abc"`
+	}
+	d := New()
+	if err := d.Apply(&invalid{Int: 1}); err != nil {
+		t.Errorf("Apply() on set field error = %v, want nil", err)
+	}
+	if err := d.Apply(&invalid{}); err == nil {
+		t.Errorf("Apply() on unset field error = nil, want error")
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	type inner struct {
+		Values []int
+	}
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{
+			name:  "nested map",
+			input: map[string]any{"a": map[string]any{"b": []any{1.0, "x"}}},
+		},
+		{
+			name:  "slice of pointers",
+			input: []*int{intPtr(1), nil},
+		},
+		{
+			name:  "array of slices",
+			input: [2][]string{{"a"}, nil},
+		},
+		{
+			name:  "struct",
+			input: inner{Values: []int{1, 2}},
+		},
+		{
+			name:  "optional slice",
+			input: Some([]string{"a"}),
+		},
+		{
+			name:  "duration",
+			input: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deepCopy(reflect.ValueOf(tt.input)).Interface()
+			if !reflect.DeepEqual(got, tt.input) {
+				t.Errorf("deepCopy() = %v, want %v", got, tt.input)
+			}
+		})
+	}
+
+	src := map[string]any{"a": []any{1.0}}
+	dst := deepCopy(reflect.ValueOf(src)).Interface().(map[string]any)
+	dst["a"].([]any)[0] = 2.0
+	if src["a"].([]any)[0] != 1.0 {
+		t.Errorf("deepCopy() result aliases the source")
+	}
+}
+
+func TestCopyable(t *testing.T) {
+	type unexported struct {
+		a int
+	}
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want bool
+	}{
+		{name: "scalar", typ: reflect.TypeFor[int](), want: true},
+		{name: "map of any", typ: reflect.TypeFor[map[string]any](), want: true},
+		{name: "pointer to array", typ: reflect.TypeFor[*[3]int](), want: true},
+		{name: "exported struct", typ: reflect.TypeFor[testStruct](), want: true},
+		{name: "recursive struct", typ: reflect.TypeFor[testNode](), want: true},
+		{name: "optional", typ: reflect.TypeFor[Opt[[]string]](), want: true},
+		{name: "registered scalar", typ: reflect.TypeFor[time.Duration](), want: true},
+		{name: "unexported struct", typ: reflect.TypeFor[unexported](), want: false},
+		{name: "time", typ: reflect.TypeFor[time.Time](), want: false},
+		{name: "func", typ: reflect.TypeFor[func()](), want: false},
+	}
+	d := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.copyable(tt.typ); got != tt.want {
+				t.Errorf("copyable(%v) = %v, want %v", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkDefaults(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		var s test
+		if err := Defaults(&s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDefaultsUncached measures the cost of walking the type and parsing
+// every tag on each call, as Defaults did before plans were cached.
+func BenchmarkDefaultsUncached(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		var s test
+		if err := New().Apply(&s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDefaultsParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var s test
+			if err := Defaults(&s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
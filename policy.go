@@ -0,0 +1,93 @@
+package defaults
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyPolicy controls when a tagged field receives its default value.
+type ApplyPolicy int
+
+const (
+	// ApplyIfZero sets fields that are unset: zero values, nil pointers, nil (or,
+	// under EmptyLen, empty) maps and slices, and unset Presence values.
+	ApplyIfZero ApplyPolicy = iota
+	// ApplyAlways sets fields to their default whatever their current value. Fields
+	// of a value just set from a JSON default are only filled if it left them unset.
+	ApplyAlways
+	// ApplyIfNil sets only nil pointer, map, slice and interface fields,
+	// leaving other fields untouched even if they hold their zero value.
+	ApplyIfNil
+	// ApplyNever leaves fields untouched. Nested structs are still processed
+	// following their own tags.
+	ApplyNever
+)
+
+// SetApplyPolicy sets when Defaults sets tagged fields.
+// Use WithApplyPolicy to configure a Defaulter instead.
+// It can be overridden per field with the `policy=always|ifzero|ifnil|never` tag option:
+//
+//	Port int `default:"8080,policy=always"`
+func SetApplyPolicy(p ApplyPolicy) {
+	std.policy = p
+}
+
+// Reset sets every tagged field of the struct s points to, and of its nested
+// structs, to its default value, as Defaults does under ApplyAlways. Fields with
+// a policy tag option keep their own policy, so `policy=never` protects a field
+// from being reset.
+func Reset(s any) error {
+	return std.Reset(s)
+}
+
+// parseApplyPolicy parses the argument of the policy tag option.
+func parseApplyPolicy(arg string) (ApplyPolicy, error) {
+	switch arg {
+	case "always":
+		return ApplyAlways, nil
+	case "ifzero":
+		return ApplyIfZero, nil
+	case "ifnil":
+		return ApplyIfNil, nil
+	case "never":
+		return ApplyNever, nil
+	default:
+		return 0, fmt.Errorf("invalid tag option policy=%s", arg)
+	}
+}
+
+// shouldSet reports whether field fieldVal should receive its default under the
+// field's policy option or, without one, the policy of the walk.
+func (w *walker) shouldSet(fp *fieldPlan, fieldVal reflect.Value) bool {
+	policy := w.policy
+	if fp.opts.hasPolicy {
+		policy = fp.opts.policy
+	}
+	switch policy {
+	case ApplyAlways:
+		return true
+	case ApplyIfNil:
+		switch fieldVal.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			return fieldVal.IsNil()
+		default:
+			return false
+		}
+	case ApplyNever:
+		return false
+	default:
+		return w.d.isUnset(fp.unset, fieldVal)
+	}
+}
+
+// fillUnset switches the walker to ApplyIfZero for the fields of a value that was just
+// set from a JSON literal if literal is true, so that under ApplyAlways nested tags only
+// fill the fields the literal left unset instead of overwriting it. The returned
+// function restores the previous policy.
+func (w *walker) fillUnset(literal bool) (restore func()) {
+	policy := w.policy
+	if literal && policy == ApplyAlways {
+		w.policy = ApplyIfZero
+	}
+	return func() { w.policy = policy }
+}
@@ -0,0 +1,135 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testPolicy struct {
+	Port  int            `default:"8080"`
+	Name  string         `default:"app"`
+	Ptr   *int           `default:"1"`
+	Tags  []string       `default:"[\"a\"]"`
+	Inner testPolicyLeaf `default:"{\"Size\":5}"`
+}
+
+type testPolicyLeaf struct {
+	Size  int `default:"2"`
+	Limit int `default:"10"`
+}
+
+type testPolicyLiterals struct {
+	Ptr    *testPolicyLeaf  `default:"{\"Size\":5}"`
+	Leaves []testPolicyLeaf `default:"[{\"Size\":6},{\"Limit\":7}]"`
+	Plain  []testPolicyLeaf
+}
+
+type testPolicyTags struct {
+	Always int   `default:"1,policy=always"`
+	IfZero int   `default:"2,policy=ifzero"`
+	IfNil  *int  `default:"3,policy=ifnil"`
+	NotNil int   `default:"4,policy=ifnil"`
+	Never  int   `default:"5,policy=never"`
+	Slice  []int `default:"[6],policy=ifnil"`
+}
+
+func TestApplyPolicy(t *testing.T) {
+	one, seven := 1, 7
+	tests := []struct {
+		name   string
+		policy ApplyPolicy
+		input  any
+		want   any
+	}{
+		{
+			name:   "if zero",
+			policy: ApplyIfZero,
+			input:  &testPolicy{Port: 9090, Tags: []string{}},
+			want:   &testPolicy{Port: 9090, Name: "app", Ptr: &one, Tags: []string{}, Inner: testPolicyLeaf{Size: 5, Limit: 10}},
+		},
+		{
+			name:   "always",
+			policy: ApplyAlways,
+			input:  &testPolicy{Port: 9090, Name: "x", Ptr: &seven, Tags: []string{"b"}, Inner: testPolicyLeaf{Size: 1, Limit: 1}},
+			want:   &testPolicy{Port: 8080, Name: "app", Ptr: &one, Tags: []string{"a"}, Inner: testPolicyLeaf{Size: 5, Limit: 10}},
+		},
+		{
+			name:   "always keeps what literals set",
+			policy: ApplyAlways,
+			input:  &testPolicyLiterals{Ptr: &testPolicyLeaf{Limit: 1}, Plain: []testPolicyLeaf{{Size: 1}}},
+			want: &testPolicyLiterals{
+				Ptr:    &testPolicyLeaf{Size: 5, Limit: 10},
+				Leaves: []testPolicyLeaf{{Size: 6, Limit: 10}, {Size: 2, Limit: 7}},
+				Plain:  []testPolicyLeaf{{Size: 2, Limit: 10}},
+			},
+		},
+		{
+			name:   "if nil",
+			policy: ApplyIfNil,
+			input:  &testPolicy{Tags: []string{}},
+			want:   &testPolicy{Ptr: &one, Tags: []string{}},
+		},
+		{
+			name:   "never",
+			policy: ApplyNever,
+			input:  &testPolicy{Port: 9090},
+			want:   &testPolicy{Port: 9090},
+		},
+		{
+			name:   "tag options override the policy",
+			policy: ApplyNever,
+			input:  &testPolicyTags{Always: 9, NotNil: 0, Never: 0},
+			want:   &testPolicyTags{Always: 1, IfZero: 2, IfNil: intPtr(3), Slice: []int{6}},
+		},
+		{
+			name:   "tag options under always",
+			policy: ApplyAlways,
+			input:  &testPolicyTags{IfZero: 9, IfNil: &seven, Never: 9, Slice: []int{}},
+			want:   &testPolicyTags{Always: 1, IfZero: 9, IfNil: &seven, Never: 9, Slice: []int{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New(WithApplyPolicy(tt.policy)).Apply(tt.input); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("Apply() got = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	one := 1
+	got := &testPolicy{Port: 9090, Name: "x", Tags: []string{"b"}, Inner: testPolicyLeaf{Size: 1}}
+	if err := Reset(got); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	want := &testPolicy{Port: 8080, Name: "app", Ptr: &one, Tags: []string{"a"}, Inner: testPolicyLeaf{Size: 5, Limit: 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reset() got = %+v, want %+v", got, want)
+	}
+
+	tags := &testPolicyTags{IfZero: 9, Never: 9}
+	if err := New(WithApplyPolicy(ApplyNever)).Reset(tags); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if tags.Always != 1 || tags.IfZero != 9 || tags.Never != 9 {
+		t.Errorf("Reset() got = %+v, want tag policies honored", tags)
+	}
+
+	if err := Reset(nil); err == nil {
+		t.Error("Reset(nil) error = nil, want error")
+	}
+}
+
+func TestResetSkipsGenerated(t *testing.T) {
+	got := &generatedTest{Int: 1}
+	if err := Reset(got); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if got.Int != 1_000_000 {
+		t.Errorf("Reset() Int = %d, want %d", got.Int, 1_000_000)
+	}
+}
@@ -0,0 +1,257 @@
+package defaults
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderFunc computes a default value at the time it is applied, for tags of
+// the form `default:"@name"` or `default:"@name(arg1, arg2)"`. t is the type of
+// the field and args the trimmed arguments of the call, if any.
+//
+// The returned value is assigned to the field, or converted to its type. Values
+// for pointer and Opt fields may be of the element type. A string returned for a
+// field of another type is parsed like a tag value, so @env(PORT) fills an int.
+type ProviderFunc func(t reflect.Type, args []string) (reflect.Value, error)
+
+// builtinProviders holds the providers registered out of the box.
+var builtinProviders = map[string]ProviderFunc{
+	"now":      provideNow,
+	"uuid":     provideUUID,
+	"hostname": provideHostname,
+	"numcpu":   provideNumCPU,
+	"tempdir":  provideTempDir,
+	"env":      provideEnv,
+}
+
+// providers is the package-level provider registry consulted by Defaults.
+var providers = struct {
+	mu    sync.RWMutex
+	funcs map[string]ProviderFunc
+}{funcs: cloneProviders(builtinProviders)}
+
+func cloneProviders(m map[string]ProviderFunc) map[string]ProviderFunc {
+	c := make(map[string]ProviderFunc, len(m))
+	for name, fn := range m {
+		c[name] = fn
+	}
+	return c
+}
+
+// RegisterProvider registers fn as the provider called by tags of the form
+// `default:"@name"`, replacing any provider previously registered under name,
+// including the built-in ones:
+//
+//	@now          time.Now(), or the clock set with SetClock
+//	@uuid         a random (version 4) UUID
+//	@hostname     os.Hostname()
+//	@numcpu       runtime.NumCPU()
+//	@tempdir      os.TempDir()
+//	@env(NAME)    the variable NAME of SetLookup's function, os.LookupEnv by default,
+//	              or @env(NAME, fallback)
+//
+// It panics if name is not made of letters, digits and underscores or fn is nil.
+func RegisterProvider(name string, fn ProviderFunc) {
+	if !isVarName(name) {
+		panic("defaults: RegisterProvider called with invalid name " + name)
+	}
+	if fn == nil {
+		panic("defaults: RegisterProvider called with nil provider")
+	}
+	providers.mu.Lock()
+	defer providers.mu.Unlock()
+	providers.funcs[name] = fn
+}
+
+// UnregisterProvider removes the provider registered under name, if any.
+// It reports whether a provider was removed.
+func UnregisterProvider(name string) bool {
+	providers.mu.Lock()
+	defer providers.mu.Unlock()
+	if _, ok := providers.funcs[name]; !ok {
+		return false
+	}
+	delete(providers.funcs, name)
+	return true
+}
+
+// RegisteredProviders returns the names of the registered providers, sorted.
+func RegisteredProviders() []string {
+	providers.mu.RLock()
+	defer providers.mu.RUnlock()
+	names := make([]string, 0, len(providers.funcs))
+	for name := range providers.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProvider registers fn as the provider for name on this Defaulter only,
+// taking precedence over providers registered with RegisterProvider. Tests use
+// it to make providers such as @now deterministic.
+func WithProvider(name string, fn ProviderFunc) Option {
+	return func(d *Defaulter) {
+		if !isVarName(name) || fn == nil {
+			return
+		}
+		funcs := cloneProviders(d.providers)
+		funcs[name] = fn
+		d.providers = funcs
+	}
+}
+
+// isProviderCall reports whether a tag value, as written, calls a provider, that
+// is whether it starts with a single @. A leading @@ stands for a literal @.
+func isProviderCall(value string) bool {
+	return strings.HasPrefix(value, "@") && !strings.HasPrefix(value, "@@")
+}
+
+// parseProviderCall splits a provider call such as @env(HOME) into its name and arguments.
+func parseProviderCall(value string) (string, []string, error) {
+	call := value[1:]
+	name, rest, hasArgs := strings.Cut(call, "(")
+	if !isVarName(name) || (hasArgs && !strings.HasSuffix(rest, ")")) {
+		return "", nil, fmt.Errorf("%w: invalid provider call %q", ErrSyntax, value)
+	}
+	var args []string
+	if rest = strings.TrimSuffix(rest, ")"); strings.TrimSpace(rest) != "" {
+		for arg := range strings.SplitSeq(rest, ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	}
+	return name, args, nil
+}
+
+// parseDefault parses value, the tag value raw with its references expanded, into
+// a value of type t. Whether it calls a provider, or starts with an escaped @@, is
+// decided from raw, so that expanded variables and fields are never taken for calls.
+func (d *Defaulter) parseDefault(t reflect.Type, raw, value string, vo valueOptions) (reflect.Value, error) {
+	if !isProviderCall(raw) {
+		if strings.HasPrefix(raw, "@@") {
+			value = value[1:]
+		}
+		return d.parseValue(t, value, vo)
+	}
+	name, args, err := parseProviderCall(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	fn, ok := d.providers[name]
+	if !ok {
+		providers.mu.RLock()
+		fn, ok = providers.funcs[name]
+		providers.mu.RUnlock()
+	}
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown provider @%s", name)
+	}
+	val, err := d.callProvider(fn, t, args)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("provider @%s failed: %w", name, err)
+	}
+	return d.convertProvided(t, val, name, vo)
+}
+
+// callProvider calls provider fn. The built-in @now and @env read the Defaulter's
+// clock and lookup function instead of time.Now and os.LookupEnv.
+func (d *Defaulter) callProvider(fn ProviderFunc, t reflect.Type, args []string) (reflect.Value, error) {
+	switch {
+	case sameFunc(fn, provideNow):
+		return reflect.ValueOf(d.now()), nil
+	case sameFunc(fn, provideEnv):
+		return lookupEnv(d.interpolator().lookup, args)
+	}
+	return fn(t, args)
+}
+
+// convertProvided converts the value returned by provider name to type t.
+func (d *Defaulter) convertProvided(t reflect.Type, val reflect.Value, name string, vo valueOptions) (reflect.Value, error) {
+	switch {
+	case !val.IsValid():
+		return reflect.Zero(t), nil
+	case val.Type().AssignableTo(t):
+		return val, nil
+	case t.Kind() == reflect.Ptr:
+		elem, err := d.convertProvided(t.Elem(), val, name, vo)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+	if opt, ok := reflect.New(t).Interface().(optional); ok {
+		elem, err := d.convertProvided(opt.valueType(), val, name, vo)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		opt.setValue(elem)
+		return reflect.ValueOf(opt).Elem(), nil
+	}
+	switch {
+	case val.Kind() == reflect.String && t.Kind() == reflect.String:
+		return val.Convert(t), nil
+	case val.Kind() == reflect.String:
+		return d.parseValue(t, val.String(), vo)
+	case t.Kind() != reflect.String && val.Type().ConvertibleTo(t):
+		// Numeric values are converted, but not to strings, which would yield runes
+		return val.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("provider @%s returned %v, which cannot be converted to field type %v", name, val.Type(), t)
+}
+
+func provideNow(reflect.Type, []string) (reflect.Value, error) {
+	return reflect.ValueOf(time.Now()), nil
+}
+
+func provideUUID(reflect.Type, []string) (reflect.Value, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return reflect.Value{}, err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return reflect.ValueOf(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])), nil
+}
+
+func provideHostname(reflect.Type, []string) (reflect.Value, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(name), nil
+}
+
+func provideNumCPU(reflect.Type, []string) (reflect.Value, error) {
+	return reflect.ValueOf(runtime.NumCPU()), nil
+}
+
+func provideTempDir(reflect.Type, []string) (reflect.Value, error) {
+	return reflect.ValueOf(os.TempDir()), nil
+}
+
+func provideEnv(_ reflect.Type, args []string) (reflect.Value, error) {
+	return lookupEnv(os.LookupEnv, args)
+}
+
+// lookupEnv implements @env(NAME) and @env(NAME, fallback) with lookup.
+func lookupEnv(lookup LookupFunc, args []string) (reflect.Value, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return reflect.Value{}, fmt.Errorf("want @env(NAME) or @env(NAME, fallback), got %d arguments", len(args))
+	}
+	if v, ok := lookup(args[0]); ok {
+		return reflect.ValueOf(v), nil
+	}
+	if len(args) == 2 {
+		return reflect.ValueOf(args[1]), nil
+	}
+	return reflect.Value{}, fmt.Errorf("environment variable %s is not set", args[0])
+}
@@ -0,0 +1,242 @@
+package defaults
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func fixedNow(reflect.Type, []string) (reflect.Value, error) {
+	return reflect.ValueOf(testNow), nil
+}
+
+type testProviders struct {
+	Started  time.Time      `default:"@now"`
+	Deadline *time.Time     `default:"@now"`
+	Workers  int            `default:"@numcpu"`
+	Threads  *uint16        `default:"@numcpu"`
+	Temp     string         `default:"@tempdir"`
+	Port     int            `default:"@env(TEST_PROVIDER_PORT, 8080)"`
+	Handle   string         `default:"@@admin"`
+	Names    []string       `default:"[\"@now\"]"`
+	Created  Opt[time.Time] `default:"@now"`
+}
+
+func TestProviders(t *testing.T) {
+	got := &testProviders{}
+	if err := New(WithProvider("now", fixedNow)).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	threads := uint16(runtime.NumCPU())
+	want := &testProviders{
+		Started:  testNow,
+		Deadline: &testNow,
+		Workers:  runtime.NumCPU(),
+		Threads:  &threads,
+		Temp:     os.TempDir(),
+		Port:     8080,
+		Handle:   "@admin",
+		Names:    []string{"@now"},
+		Created:  Some(testNow),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestProvidersUseDefaulterSettings(t *testing.T) {
+	t.Setenv("TEST_PROVIDER_PORT", "9090")
+	d := New(
+		WithClock(func() time.Time { return testNow }),
+		WithLookup(MapLookup(map[string]string{"TEST_PROVIDER_PORT": "7070"})),
+	)
+	got := &struct {
+		Started time.Time `default:"@now"`
+		Port    int       `default:"@env(TEST_PROVIDER_PORT)"`
+		Host    string    `default:"@env(TEST_PROVIDER_HOST, localhost)"`
+	}{}
+	if err := d.Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !got.Started.Equal(testNow) || got.Port != 7070 || got.Host != "localhost" {
+		t.Errorf("Apply() got = %+v, want Started %v, Port 7070 and Host localhost", got, testNow)
+	}
+
+	// Providers registered under the same names replace the built-in ones
+	d = New(WithClock(time.Now), WithProvider("now", fixedNow))
+	got.Started = time.Time{}
+	if err := d.Apply(got); err != nil || !got.Started.Equal(testNow) {
+		t.Errorf("Apply() Started = %v, %v, want %v", got.Started, err, testNow)
+	}
+}
+
+func TestExpandedValuesAreNotProviderCalls(t *testing.T) {
+	d := New(WithLookup(MapLookup(map[string]string{"TEAM": "@hostname", "OTHER": "@platform"})))
+	got := &struct {
+		Team  string `default:"${TEAM}"`
+		Other string `default:"${OTHER}"`
+		Owner string `default:"@@ops"`
+		Copy  string `default:"${.Owner}"`
+		Both  string `default:"@@${TEAM}"`
+	}{}
+	if err := d.Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.Team != "@hostname" || got.Other != "@platform" || got.Owner != "@ops" || got.Copy != "@ops" || got.Both != "@@hostname" {
+		t.Errorf("Apply() got = %+v, want the expanded values unchanged", got)
+	}
+
+	// A fallback is not a provider call either
+	if err := ValidateTag(reflect.TypeFor[string](), "${TEAM:-@core team}"); err != nil {
+		t.Errorf("ValidateTag() error = %v", err)
+	}
+}
+
+func TestProvidersCalledOnEveryApply(t *testing.T) {
+	n := 0
+	d := New(WithProvider("counter", func(reflect.Type, []string) (reflect.Value, error) {
+		n++
+		return reflect.ValueOf(n), nil
+	}))
+	for want := 1; want <= 2; want++ {
+		got := &struct {
+			N int `default:"@counter"`
+		}{}
+		if err := d.Apply(got); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		if got.N != want {
+			t.Errorf("N = %d, want %d", got.N, want)
+		}
+	}
+}
+
+func TestBuiltinProviders(t *testing.T) {
+	t.Setenv("TEST_PROVIDER_PORT", "9090")
+	got := &struct {
+		ID   string `default:"@uuid"`
+		Host string `default:"@hostname"`
+		Port int    `default:"@env(TEST_PROVIDER_PORT)"`
+	}{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(got.ID) {
+		t.Errorf("ID = %q, want a version 4 UUID", got.ID)
+	}
+	if host, _ := os.Hostname(); got.Host != host {
+		t.Errorf("Host = %q, want %q", got.Host, host)
+	}
+	if got.Port != 9090 {
+		t.Errorf("Port = %d, want 9090", got.Port)
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		wantIs    error
+		errString string
+	}{
+		{
+			name: "unknown provider",
+			input: &struct {
+				V string `default:"@nope"`
+			}{},
+			errString: "failed to set default for field V: unknown provider @nope",
+		},
+		{
+			name: "invalid call",
+			input: &struct {
+				V string `default:"@env(HOME"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid syntax: invalid provider call "@env(HOME"`,
+		},
+		{
+			name: "provider failure",
+			input: &struct {
+				V string `default:"@env(TEST_PROVIDER_UNSET)"`
+			}{},
+			errString: "failed to set default for field V: provider @env failed: environment variable TEST_PROVIDER_UNSET is not set",
+		},
+		{
+			name: "unparsable string",
+			input: &struct {
+				V int8 `default:"@tempdir"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: "failed to set default for field V: strconv.ParseInt: parsing",
+		},
+		{
+			name: "inconvertible value",
+			input: &struct {
+				V string `default:"@numcpu"`
+			}{},
+			errString: "failed to set default for field V: provider @numcpu returned int, which cannot be converted to field type string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errString) {
+				t.Errorf("Defaults() error = %v, want prefix %q", err, tt.errString)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	RegisterProvider("region", func(_ reflect.Type, args []string) (reflect.Value, error) {
+		return reflect.ValueOf("eu-" + strings.Join(args, "-")), nil
+	})
+	t.Cleanup(func() { UnregisterProvider("region") })
+
+	got := &struct {
+		Region string `default:"@region(west, 1)"`
+	}{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if got.Region != "eu-west-1" {
+		t.Errorf("Region = %q, want %q", got.Region, "eu-west-1")
+	}
+
+	names := RegisteredProviders()
+	want := []string{"env", "hostname", "now", "numcpu", "region", "tempdir", "uuid"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("RegisteredProviders() = %v, want %v", names, want)
+	}
+	if !UnregisterProvider("region") || UnregisterProvider("region") {
+		t.Error("UnregisterProvider() did not report the removal once")
+	}
+
+	for _, tt := range []struct {
+		name     string
+		provider string
+		fn       ProviderFunc
+	}{
+		{name: "invalid name", provider: "bad name", fn: fixedNow},
+		{name: "nil provider", provider: "nil", fn: nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterProvider() did not panic")
+				}
+			}()
+			RegisterProvider(tt.provider, tt.fn)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package defaults
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes. Fields of type ByteSize always accept the quantity
+// syntax, as in `default:"64MiB"`, and String prints them back in the same form.
+type ByteSize uint64
+
+// Decimal (SI) and binary (IEC) multiples of a byte.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1 << 10
+	MiB ByteSize = 1 << 20
+	GiB ByteSize = 1 << 30
+	TiB ByteSize = 1 << 40
+	PiB ByteSize = 1 << 50
+	EiB ByteSize = 1 << 60
+)
+
+// String returns b in the largest binary unit it reaches, rounded to two decimals,
+// such as 512B, 64MiB or 1.5GiB.
+func (b ByteSize) String() string {
+	units := []struct {
+		size ByteSize
+		name string
+	}{{EiB, "EiB"}, {PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}}
+	for _, u := range units {
+		if b < u.size {
+			continue
+		}
+		if b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+		s := strconv.FormatFloat(float64(b)/float64(u.size), 'f', 2, 64)
+		return strings.TrimRight(strings.TrimRight(s, "0"), ".") + u.name
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// SetQuantitySyntax enables or disables the quantity syntax for the integer and
+// float fields of Defaults. It is disabled by default. Use WithQuantitySyntax to
+// configure a Defaulter instead. It can be overridden per field with the
+// `quantity` or `quantity=false` tag option:
+//
+//	MaxBody int64 `default:"10MiB,quantity"`
+func SetQuantitySyntax(enabled bool) {
+	std.quantity = enabled
+}
+
+// WithQuantitySyntax enables or disables the quantity syntax for integer and float fields.
+func WithQuantitySyntax(enabled bool) Option {
+	return func(d *Defaulter) {
+		d.quantity = enabled
+	}
+}
+
+// quantityUnits maps the unit suffixes of the quantity syntax to their multiplier.
+var quantityUnits = map[string]uint64{
+	"": 1, "B": 1,
+	"k": 1e3, "K": 1e3, "kB": 1e3, "KB": 1e3,
+	"M": 1e6, "MB": 1e6,
+	"G": 1e9, "GB": 1e9,
+	"T": 1e12, "TB": 1e12,
+	"P": 1e15, "PB": 1e15,
+	"E": 1e18, "EB": 1e18,
+	"Ki": 1 << 10, "KiB": 1 << 10,
+	"Mi": 1 << 20, "MiB": 1 << 20,
+	"Gi": 1 << 30, "GiB": 1 << 30,
+	"Ti": 1 << 40, "TiB": 1 << 40,
+	"Pi": 1 << 50, "PiB": 1 << 50,
+	"Ei": 1 << 60, "EiB": 1 << 60,
+}
+
+// maxQuantityExp bounds the decimal exponent of a quantity, far beyond the range
+// of float64, so that huge exponents fail fast instead of building huge numbers.
+const maxQuantityExp = 400
+
+// ParseQuantity parses a string to an integer or float type with the quantity syntax:
+// a decimal number, optionally with a fraction and an exponent, followed by an
+// optional SI (k, M, G, T, P, E, with an optional B) or IEC (Ki, Mi, Gi, Ti, Pi, Ei,
+// with an optional B) unit, as in 10k, 2e6, 1.5GB or 64MiB.
+//
+// Integer types require a whole number of units' worth, so 1.5KiB is 1536 but 1.5B
+// is invalid. Values that do not fit the type are reported like strconv's range errors.
+func ParseQuantity(str string, t reflect.Type) (reflect.Value, error) {
+	var fn string
+	var bitSize int
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fn, bitSize = "ParseInt", t.Bits()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fn, bitSize = "ParseUint", t.Bits()
+	case reflect.Float32, reflect.Float64:
+		fn, bitSize = "ParseFloat", t.Bits()
+	default:
+		return reflect.Value{}, ErrUnsupportedType
+	}
+	rangeErr := &strconv.NumError{Func: fn, Num: str, Err: strconv.ErrRange}
+
+	q, err := parseQuantity(str)
+	if err != nil {
+		if err == strconv.ErrRange {
+			return reflect.Value{}, rangeErr
+		}
+		return reflect.Value{}, err
+	}
+	if fn == "ParseFloat" {
+		f, _ := q.Float64()
+		if math.IsInf(f, 0) || bitSize == 32 && math.Abs(f) > math.MaxFloat32 {
+			return reflect.Value{}, rangeErr
+		}
+		return reflect.ValueOf(f).Convert(t), nil
+	}
+
+	if !q.IsInt() {
+		return reflect.Value{}, fmt.Errorf("%w: %q is not a whole number", ErrSyntax, str)
+	}
+	n := q.Num()
+	lo, hi := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bitSize))
+	if fn == "ParseInt" {
+		hi.Rsh(hi, 1)
+		lo.Neg(hi)
+	}
+	if n.Cmp(lo) < 0 || n.Cmp(hi) >= 0 {
+		return reflect.Value{}, rangeErr
+	}
+	if fn == "ParseInt" {
+		return reflect.ValueOf(n.Int64()).Convert(t), nil
+	}
+	return reflect.ValueOf(n.Uint64()).Convert(t), nil
+}
+
+// parseQuantity parses str into the exact number it denotes. It returns
+// strconv.ErrRange for exponents beyond maxQuantityExp.
+func parseQuantity(str string) (*big.Rat, error) {
+	num, unit := splitQuantity(str)
+	mant, exp, _ := strings.Cut(strings.ToLower(num), "e")
+	if strings.Trim(mant, "+-.") == "" {
+		return nil, fmt.Errorf("%w: %q is not a quantity", ErrSyntax, str)
+	}
+	mult, ok := quantityUnits[strings.TrimSpace(unit)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown unit %q in %q", ErrSyntax, strings.TrimSpace(unit), str)
+	}
+	if exp != "" {
+		if e, err := strconv.Atoi(exp); err != nil || e > maxQuantityExp || e < -maxQuantityExp {
+			return nil, strconv.ErrRange
+		}
+	}
+	q, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a quantity", ErrSyntax, str)
+	}
+	return q.Mul(q, new(big.Rat).SetUint64(mult)), nil
+}
+
+// splitQuantity splits str into its decimal number, [+-]digits[.digits][e[+-]digits],
+// and the unit that follows. An e or E only starts an exponent if digits follow,
+// so 2E is two exa.
+func splitQuantity(str string) (num, unit string) {
+	i := 0
+	if i < len(str) && (str[i] == '+' || str[i] == '-') {
+		i++
+	}
+	i = skipDigits(str, i)
+	if i < len(str) && str[i] == '.' {
+		i = skipDigits(str, i+1)
+	}
+	if i < len(str) && (str[i] == 'e' || str[i] == 'E') {
+		j := i + 1
+		if j < len(str) && (str[j] == '+' || str[j] == '-') {
+			j++
+		}
+		if k := skipDigits(str, j); k > j {
+			i = k
+		}
+	}
+	return str[:i], str[i:]
+}
+
+// skipDigits returns the index of the first non-digit of str at or after i.
+func skipDigits(str string, i int) int {
+	for i < len(str) && '0' <= str[i] && str[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// acceptsQuantity reports whether fields of type t hold integers or floats that can
+// be written with the quantity syntax (see holds).
+func acceptsQuantity(t reflect.Type) bool {
+	return holds(t, func(t reflect.Type) bool { return isQuantityKind(t.Kind()) })
+}
+
+// isQuantityKind reports whether values of kind k can be written with the quantity syntax.
+func isQuantityKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
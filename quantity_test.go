@@ -0,0 +1,167 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testQuantities struct {
+	Buffer   int64            `default:"64MiB,quantity"`
+	Limit    uint64           `default:"1.5GB,quantity"`
+	Requests int              `default:"10k,quantity"`
+	Rate     float64          `default:"2e6,quantity"`
+	Ratio    float32          `default:"1.5k,quantity"`
+	Offset   int32            `default:"-2Ki,quantity"`
+	Spaced   uint32           `default:"4 KiB,quantity"`
+	Plain    int              `default:"42,quantity"`
+	MaxBody  ByteSize         `default:"10MiB"`
+	Sizes    []ByteSize       `default:"1KB,2KiB,syntax=short"`
+	Chunks   []int            `default:"1k, 2Mi,syntax=short,quantity"`
+	Optional Opt[int64]       `default:"3G,quantity"`
+	Pointer  *uint16          `default:"32Ki,quantity"`
+	Limits   map[string]int64 `default:"read:1M,write:512Ki,syntax=short,quantity"`
+	Label    string           `default:"hello,quantity"`
+}
+
+func TestQuantitySyntax(t *testing.T) {
+	pointer := uint16(32 << 10)
+	got := &testQuantities{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	want := &testQuantities{
+		Buffer:   64 << 20,
+		Limit:    1_500_000_000,
+		Requests: 10_000,
+		Rate:     2e6,
+		Ratio:    1500,
+		Offset:   -2048,
+		Spaced:   4096,
+		Plain:    42,
+		MaxBody:  10 * MiB,
+		Sizes:    []ByteSize{KB, 2 * KiB},
+		Chunks:   []int{1000, 2 << 20},
+		Optional: Some(int64(3e9)),
+		Pointer:  &pointer,
+		Limits:   map[string]int64{"read": 1e6, "write": 512 << 10},
+		Label:    "hello,quantity",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() got = %+v, want %+v", got, want)
+	}
+}
+
+type testQuantityGlobal struct {
+	Size  int    `default:"16Ki"`
+	Count uint8  `default:"0x10,quantity=false"`
+	Name  string `default:"1k"`
+}
+
+func TestWithQuantitySyntax(t *testing.T) {
+	want := &testQuantityGlobal{Size: 16 << 10, Count: 16, Name: "1k"}
+	got := &testQuantityGlobal{}
+	if err := New(WithQuantitySyntax(true)).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+
+	// Plans compiled without the quantity syntax are not reused with it
+	t.Cleanup(func() { SetQuantitySyntax(false) })
+	if err := Defaults(&testQuantityGlobal{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("Defaults() error = %v, want ErrSyntax", err)
+	}
+	SetQuantitySyntax(true)
+	got = &testQuantityGlobal{}
+	if err := Defaults(got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		typ       reflect.Type
+		want      any
+		wantIs    error
+		errString string
+	}{
+		{name: "SI", input: "10k", typ: reflect.TypeFor[int](), want: 10_000},
+		{name: "SI uppercase K", input: "10K", typ: reflect.TypeFor[int](), want: 10_000},
+		{name: "SI bytes", input: "3MB", typ: reflect.TypeFor[uint64](), want: uint64(3_000_000)},
+		{name: "IEC", input: "64MiB", typ: reflect.TypeFor[int64](), want: int64(64 << 20)},
+		{name: "IEC without B", input: "1Gi", typ: reflect.TypeFor[int64](), want: int64(1 << 30)},
+		{name: "exa is not an exponent", input: "2E", typ: reflect.TypeFor[int64](), want: int64(2e18)},
+		{name: "exponent", input: "2e6", typ: reflect.TypeFor[int](), want: 2_000_000},
+		{name: "negative exponent", input: "1.5e-3k", typ: reflect.TypeFor[float64](), want: 1.5},
+		{name: "fraction", input: "0.5KiB", typ: reflect.TypeFor[int](), want: 512},
+		{name: "bytes", input: "512B", typ: reflect.TypeFor[int](), want: 512},
+		{name: "named type", input: "1Ki", typ: reflect.TypeFor[ByteSize](), want: KiB},
+		{name: "int8 bound", input: "-128", typ: reflect.TypeFor[int8](), want: int8(-128)},
+		{name: "uint64 max", input: "16EiB", typ: reflect.TypeFor[uint64](), wantIs: ErrOutOfRange, errString: `strconv.ParseUint: parsing "16EiB": value out of range`},
+		{name: "int overflow", input: "1k", typ: reflect.TypeFor[int8](), wantIs: ErrOutOfRange, errString: `strconv.ParseInt: parsing "1k": value out of range`},
+		{name: "negative unsigned", input: "-1k", typ: reflect.TypeFor[uint](), wantIs: ErrOutOfRange, errString: `strconv.ParseUint: parsing "-1k": value out of range`},
+		{name: "float32 overflow", input: "1e39", typ: reflect.TypeFor[float32](), wantIs: ErrOutOfRange, errString: `strconv.ParseFloat: parsing "1e39": value out of range`},
+		{name: "huge exponent", input: "1e1000000", typ: reflect.TypeFor[float64](), wantIs: ErrOutOfRange, errString: `strconv.ParseFloat: parsing "1e1000000": value out of range`},
+		{name: "not whole", input: "1.5B", typ: reflect.TypeFor[int](), wantIs: ErrSyntax, errString: `invalid syntax: "1.5B" is not a whole number`},
+		{name: "unknown unit", input: "10mb", typ: reflect.TypeFor[int](), wantIs: ErrSyntax, errString: `invalid syntax: unknown unit "mb" in "10mb"`},
+		{name: "no number", input: "MiB", typ: reflect.TypeFor[int](), wantIs: ErrSyntax, errString: `invalid syntax: "MiB" is not a quantity`},
+		{name: "unsupported type", input: "1k", typ: reflect.TypeFor[string](), wantIs: ErrUnsupportedType, errString: "unsupported type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuantity(tt.input, tt.typ)
+			if tt.wantIs != nil {
+				fe := &FieldError{Err: err}
+				if !errors.Is(fe, tt.wantIs) {
+					t.Errorf("ParseQuantity() error = %v, want %v", err, tt.wantIs)
+				}
+				if err == nil || err.Error() != tt.errString {
+					t.Errorf("ParseQuantity() error = %v, want %q", err, tt.errString)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuantity() error = %v", err)
+			}
+			if got.Type() != tt.typ || got.Interface() != tt.want {
+				t.Errorf("ParseQuantity() = %v (%v), want %v (%v)", got, got.Type(), tt.want, tt.typ)
+			}
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		input ByteSize
+		want  string
+	}{
+		{input: 0, want: "0B"},
+		{input: 512, want: "512B"},
+		{input: KiB, want: "1KiB"},
+		{input: 1536, want: "1.5KiB"},
+		{input: 1500, want: "1.46KiB"},
+		{input: 64 * MiB, want: "64MiB"},
+		{input: GB, want: "953.67MiB"},
+		{input: 15 * EiB, want: "15EiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.input.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(tt.want, ".") {
+				return
+			}
+			// Exact values parse back to themselves
+			got, err := ParseQuantity(tt.want, reflect.TypeFor[ByteSize]())
+			if err != nil || got.Interface() != tt.input {
+				t.Errorf("ParseQuantity(%q) = %v, %v, want %v", tt.want, got, err, tt.input)
+			}
+		})
+	}
+}
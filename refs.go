@@ -0,0 +1,274 @@
+package defaults
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// Field references
+//
+// A tag value may refer to other fields of the struct, or of the structs it is
+// nested in, as in
+//
+//	AdminURL string `default:"http://${.Host}:${.AdminPort}"`
+//
+// Each leading dot after the first moves up one struct: ${.Host} is a sibling
+// field and ${..Host} a field of the parent struct. The path may go down into
+// nested structs, as in ${.Server.Port}. Fields holding references are set in a
+// second phase, once every other field, nested struct and SetDefaults method was
+// processed, in the order implied by their references. A referenced field that
+// is still zero, or behind a nil pointer, uses the fallback of ${.Field:-fallback},
+// if any.
+
+// hasFieldRefs reports whether a tag value holds ${.Field} references.
+func hasFieldRefs(value string) bool {
+	return strings.Contains(value, "${.")
+}
+
+// isRefPath reports whether name is a field reference such as .Host or ..Server.Port.
+func isRefPath(name string) bool {
+	rest := strings.TrimLeft(name, ".")
+	if len(rest) == len(name) || rest == "" {
+		return false
+	}
+	for field := range strings.SplitSeq(rest, ".") {
+		if !isVarName(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// deferred is a field whose default holds field references, set once the rest of
+// the struct tree was defaulted.
+type deferred struct {
+	t     reflect.Type // type of the struct declaring the field
+	fp    *fieldPlan
+	field reflect.Value
+	scope []reflect.Value // structs from the root to the one declaring the field
+	path  []string        // walker path of the declaring struct
+	errAt int             // number of failures collected before the field was reached
+
+	state int   // resolvePending, resolveActive or resolveDone
+	err   error // failure once resolveDone
+}
+
+const (
+	resolvePending = iota
+	resolveActive
+	resolveDone
+)
+
+// deferField records a field holding references to set in the second phase.
+func (w *walker) deferField(t reflect.Type, fp *fieldPlan, fieldVal reflect.Value) {
+	df := &deferred{
+		t:     t,
+		fp:    fp,
+		field: fieldVal,
+		scope: slices.Clone(w.scope),
+		path:  slices.Clone(w.path),
+		errAt: len(w.errs),
+	}
+	w.deferred = append(w.deferred, df)
+	if w.deferredAt == nil {
+		w.deferredAt = make(map[visit]*deferred)
+	}
+	w.deferredAt[visit{ptr: fieldVal.Addr().Pointer(), typ: fieldVal.Type()}] = df
+}
+
+// resolveDeferred sets the deferred fields in declaration order, resolving the
+// fields they refer to first. Their failures are collected among those of the
+// walk, in declaration order.
+func (w *walker) resolveDeferred() error {
+	// Store back map values that were defaulted on a copy
+	defer func() {
+		for _, fn := range w.fixups {
+			fn()
+		}
+	}()
+	var errs []error
+	next := 0 // first failure of the walk not yet in errs
+	for _, df := range w.deferred {
+		err := w.resolve(df)
+		if err == nil {
+			continue
+		}
+		if !w.d.errors.Collect {
+			return err
+		}
+		errs = append(errs, w.errs[next:df.errAt]...)
+		errs = append(errs, err)
+		next = df.errAt
+	}
+	if errs == nil {
+		return nil
+	}
+	w.errs = append(errs, w.errs[next:]...)
+	if limit := w.d.errors.MaxErrors; limit > 0 && len(w.errs) > limit {
+		w.errs = w.errs[:limit]
+		return errTooMany
+	}
+	return nil
+}
+
+// resolve sets a deferred field unless done already, and returns its failure.
+func (w *walker) resolve(df *deferred) error {
+	switch df.state {
+	case resolveDone:
+		return df.err
+	case resolveActive:
+		// Reported by the field referring to df
+		return nil
+	}
+	df.state = resolveActive
+	w.active = append(w.active, df)
+	defer func() {
+		w.active = w.active[:len(w.active)-1]
+		df.state = resolveDone
+	}()
+
+	in := w.d.interpolator()
+	in.ref = func(ref string) (string, bool, error) {
+		return w.refValue(df, ref)
+	}
+	path := w.path
+	w.path = df.path
+	defer func() { w.path = path }()
+
+	value, err := in.expand(df.fp.value)
+	if err != nil {
+		df.err = w.fieldError(df.t, df.fp, err)
+		return df.err
+	}
+	val, err := w.d.parseDefault(df.fp.field.Type, df.fp.value, value, df.fp.vopts)
+	if err != nil {
+		df.err = w.fieldError(df.t, df.fp, err)
+		return df.err
+	}
+	df.err = w.setValue(df.t, df.fp, df.field, val)
+	return df.err
+}
+
+// refValue returns the formatted value of the field ref refers to from the
+// struct declaring df, resolving it first if it is deferred itself.
+func (w *walker) refValue(df *deferred, ref string) (string, bool, error) {
+	rest := strings.TrimLeft(ref, ".")
+	up := len(ref) - len(rest) - 1
+	if up >= len(df.scope) {
+		return "", false, fmt.Errorf("%w: ${%s} goes above the root struct", ErrReference, ref)
+	}
+	v := df.scope[len(df.scope)-1-up]
+	for name := range strings.SplitSeq(rest, ".") {
+		var ok bool
+		if v, ok = derefRef(v); !ok {
+			return "", false, fmt.Errorf("%w: ${%s}: %w before %s", ErrReference, ref, errNilRef, name)
+		}
+		if v.Kind() != reflect.Struct {
+			return "", false, fmt.Errorf("%w: ${%s}: %s is not a struct", ErrReference, ref, v.Type())
+		}
+		f, ok := v.Type().FieldByName(name)
+		if !ok || !f.IsExported() {
+			return "", false, fmt.Errorf("%w: ${%s}: no exported field %s in %s", ErrReference, ref, name, v.Type())
+		}
+		// Fields promoted from embedded struct pointers are reached through each of them
+		for i, x := range f.Index {
+			if i > 0 {
+				if v, ok = derefRef(v); !ok {
+					return "", false, fmt.Errorf("%w: ${%s}: %w before %s", ErrReference, ref, errNilRef, name)
+				}
+			}
+			v = v.Field(x)
+		}
+	}
+
+	if target, ok := w.deferredAt[visit{ptr: v.Addr().Pointer(), typ: v.Type()}]; ok {
+		if target.state == resolveActive {
+			return "", false, w.cycleError(target)
+		}
+		if err := w.resolve(target); err != nil {
+			var fe *FieldError
+			if errors.As(err, &fe) {
+				err = fe.Err
+			}
+			return "", false, fmt.Errorf("${%s}: %w", ref, err)
+		}
+	}
+	return formatRef(v)
+}
+
+// errNilRef reports a nil pointer on the path of a field reference. The fallback
+// of the reference, if any, applies instead.
+var errNilRef = errors.New("nil pointer")
+
+// derefRef follows the pointers of v on the path of a field reference. It reports
+// false if one of them is nil.
+func derefRef(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// cycleError describes the chain of references from target back to itself.
+func (w *walker) cycleError(target *deferred) error {
+	var chain []string
+	for _, df := range w.active[slices.Index(w.active, target):] {
+		chain = append(chain, deferredPath(df))
+	}
+	chain = append(chain, deferredPath(target))
+	return fmt.Errorf("%w: reference cycle %s", ErrCycle, strings.Join(chain, " -> "))
+}
+
+// deferredPath returns the path of a deferred field from the root struct.
+func deferredPath(df *deferred) string {
+	w := walker{path: df.path}
+	return w.fieldPath(df.fp.field.Name)
+}
+
+// formatRef formats a referenced field for substitution in a tag value. It
+// reports false for zero values, so that the fallback of the reference applies.
+func formatRef(v reflect.Value) (string, bool, error) {
+	if opt, ok := v.Addr().Interface().(optional); ok {
+		if !opt.IsSet() {
+			return "", false, nil
+		}
+		v = opt.wrapped()
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	zero := v.IsZero()
+	if zero && (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) {
+		return "", false, nil
+	}
+	iface := v.Interface()
+	if v.CanAddr() {
+		iface = v.Addr().Interface()
+	}
+	if m, ok := iface.(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), !zero, err
+	}
+	if s, ok := iface.(fmt.Stringer); ok {
+		return s.String(), !zero, nil
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		b, err := json.Marshal(v.Interface())
+		return string(b), !zero, err
+	default:
+		return fmt.Sprint(v.Interface()), !zero, nil
+	}
+}
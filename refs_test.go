@@ -0,0 +1,285 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRefServer struct {
+	Port int    `default:"9000"`
+	URL  string `default:"http://${..Host}:${.Port}"`
+}
+
+type testRefBackend struct {
+	Name string
+	Addr string `default:"${..Host}/${.Name:-default}"`
+}
+
+type testRefConfig struct {
+	AdminURL  string `default:"http://${.Host}:${.AdminPort}"`
+	Host      string `default:"localhost"`
+	AdminPort int    `default:"${.Server.Port}"`
+	Server    testRefServer
+	Backends  []testRefBackend
+	Routes    map[string]testRefBackend
+	Timeout   time.Duration `default:"5s"`
+	Deadline  time.Duration `default:"${.Timeout}"`
+	Owner     string        `default:"${.User:-nobody}"`
+	User      string
+	Tags      []string `default:"${.Extra}"`
+	Extra     []string `default:"[\"a\",\"b\"]"`
+}
+
+type testRefChain struct {
+	A string `default:"${.B}/a"`
+	B string `default:"${.C}/b"`
+	C string `default:"c"`
+}
+
+type testRefSetter struct {
+	Workers int
+	Queue   int `default:"${.Workers}"`
+}
+
+func (s *testRefSetter) SetDefaults() {
+	if s.Workers == 0 {
+		s.Workers = 4
+	}
+}
+
+// RefBase is exported so that the fields embedding it are exported
+type RefBase struct {
+	Host string `default:"base"`
+}
+
+type testRefEmbed struct {
+	*RefBase `default:",noalloc"`
+	URL      string `default:"http://${.Host}"`
+}
+
+type testRefNilFallback struct {
+	Ptr      *RefBase `default:",noalloc"`
+	PtrHost  string   `default:"${.Ptr.Host:-none}"`
+	BaseHost string   `default:"${.Host:-none}"`
+	*RefBase `default:",noalloc"`
+}
+
+func TestFieldReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		want  any
+	}{
+		{
+			name: "siblings, ancestors and nested fields",
+			input: &testRefConfig{
+				User:     "",
+				Backends: []testRefBackend{{Name: "a"}, {}},
+				Routes:   map[string]testRefBackend{"api": {Name: "api"}},
+			},
+			want: &testRefConfig{
+				AdminURL:  "http://localhost:9000",
+				Host:      "localhost",
+				AdminPort: 9000,
+				Server:    testRefServer{Port: 9000, URL: "http://localhost:9000"},
+				Backends:  []testRefBackend{{Name: "a", Addr: "localhost/a"}, {Addr: "localhost/default"}},
+				Routes:    map[string]testRefBackend{"api": {Name: "api", Addr: "localhost/api"}},
+				Timeout:   5 * time.Second,
+				Deadline:  5 * time.Second,
+				Owner:     "nobody",
+				Tags:      []string{"a", "b"},
+				Extra:     []string{"a", "b"},
+			},
+		},
+		{
+			name:  "set values are referenced and kept",
+			input: &testRefConfig{Host: "example.com", AdminURL: "set", User: "root"},
+			want: &testRefConfig{
+				AdminURL:  "set",
+				Host:      "example.com",
+				AdminPort: 9000,
+				Server:    testRefServer{Port: 9000, URL: "http://example.com:9000"},
+				Timeout:   5 * time.Second,
+				Deadline:  5 * time.Second,
+				Owner:     "root",
+				User:      "root",
+				Tags:      []string{"a", "b"},
+				Extra:     []string{"a", "b"},
+			},
+		},
+		{
+			name:  "dependency order",
+			input: &testRefChain{},
+			want:  &testRefChain{A: "c/b/a", B: "c/b", C: "c"},
+		},
+		{
+			name:  "nil pointers use the fallback",
+			input: &testRefNilFallback{},
+			want:  &testRefNilFallback{PtrHost: "none", BaseHost: "none"},
+		},
+		{
+			name:  "promoted through an allocated embedded pointer",
+			input: &testRefEmbed{RefBase: &RefBase{}},
+			want:  &testRefEmbed{RefBase: &RefBase{Host: "base"}, URL: "http://base"},
+		},
+		{
+			name:  "SetDefaults values",
+			input: &testRefSetter{},
+			want:  &testRefSetter{Workers: 4, Queue: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Defaults(tt.input); err != nil {
+				t.Fatalf("Defaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("Defaults() got = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+}
+
+type testRefCycle struct {
+	A string `default:"${.B}"`
+	B string `default:"${.C}"`
+	C string `default:"${.A}"`
+	D string `default:"d"`
+}
+
+type testRefErrors struct {
+	Missing string         `default:"${.Nope}"`
+	Above   string         `default:"${..Host}"`
+	Nil     string         `default:"${.Ptr.Port}"`
+	Ptr     *testRefServer `default:",noalloc"`
+	Port    int            `default:"${.Missing}"`
+}
+
+func TestFieldReferenceErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		wantIs    error
+		wantPath  string
+		errString string
+	}{
+		{
+			name:      "cycle",
+			input:     &testRefCycle{},
+			wantIs:    ErrCycle,
+			wantPath:  "A",
+			errString: "failed to set default for field A: ${.B}: ${.C}: cycle detected: reference cycle A -> B -> C -> A",
+		},
+		{
+			name:      "unknown field",
+			input:     &testRefErrors{},
+			wantIs:    ErrReference,
+			wantPath:  "Missing",
+			errString: "failed to set default for field Missing: unresolved reference: ${.Nope}: no exported field Nope in defaults.testRefErrors",
+		},
+		{
+			name:      "promoted through a nil embedded pointer",
+			input:     &testRefEmbed{},
+			wantIs:    ErrReference,
+			wantPath:  "URL",
+			errString: "failed to set default for field URL: unresolved reference: ${.Host}: nil pointer before Host",
+		},
+		{
+			name: "not allowed in struct literals",
+			input: &struct {
+				Server testRefServer `default:"{\"Port\":\"${.Port}\"}"`
+			}{},
+			wantIs:    ErrReference,
+			wantPath:  "Server",
+			errString: "failed to set default for field Server: unresolved reference: field reference ${.Port} is not allowed here",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Defaults() error = %v, want *FieldError", err)
+			}
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if fe.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", fe.Path, tt.wantPath)
+			}
+			if err.Error() != tt.errString {
+				t.Errorf("Defaults() error = %q, want %q", err, tt.errString)
+			}
+		})
+	}
+}
+
+func TestFieldReferenceErrorsCollected(t *testing.T) {
+	got := &testRefErrors{}
+	err := New(WithErrorPolicy(ErrorPolicy{Collect: true})).Apply(got)
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("Apply() error = %v, want *MultiError", err)
+	}
+	var msgs []string
+	for _, err := range multi.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	want := []string{
+		"failed to set default for field Missing: unresolved reference: ${.Nope}: no exported field Nope in defaults.testRefErrors",
+		"failed to set default for field Above: unresolved reference: ${..Host} goes above the root struct",
+		"failed to set default for field Nil: unresolved reference: ${.Ptr.Port}: nil pointer before Port",
+		"failed to set default for field Port: ${.Missing}: unresolved reference: ${.Nope}: no exported field Nope in defaults.testRefErrors",
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(msgs, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Failures of deferred fields keep their declaration order
+	mixed := &struct {
+		A int    `default:"${.S}"`
+		C int    `default:"abc"`
+		S string `default:"zz"`
+		D int    `default:"${.S}"`
+	}{}
+	for _, policy := range []ErrorPolicy{{Collect: true}, {Collect: true, MaxErrors: 2}} {
+		err = New(WithErrorPolicy(policy)).Apply(mixed)
+		if !errors.As(err, &multi) {
+			t.Fatalf("Apply() error = %v, want *MultiError", err)
+		}
+		var paths []string
+		for _, err := range multi.Errors {
+			var fe *FieldError
+			if errors.As(err, &fe) {
+				paths = append(paths, fe.Path)
+			}
+		}
+		want := []string{"A", "C", "D"}[:len(multi.Errors)]
+		if !reflect.DeepEqual(paths, want) || multi.Truncated != (policy.MaxErrors == 2) {
+			t.Errorf("MaxErrors %d: paths = %v, truncated %v, want %v", policy.MaxErrors, paths, multi.Truncated, want)
+		}
+	}
+
+	cycle := &testRefCycle{}
+	err = New(WithErrorPolicy(ErrorPolicy{Collect: true})).Apply(cycle)
+	if !errors.As(err, &multi) || len(multi.Errors) != 3 {
+		t.Fatalf("Apply() error = %v, want 3 errors", err)
+	}
+	if cycle.D != "d" {
+		t.Errorf("D = %q, want %q", cycle.D, "d")
+	}
+}
+
+func TestFieldReferencesDisabled(t *testing.T) {
+	got := &testRefChain{}
+	if err := New(WithInterpolation(false)).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := &testRefChain{A: "${.B}/a", B: "${.C}/b", C: "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package defaults
+
+import (
+	"encoding"
+	"maps"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+)
+
+// registry holds the parsers used to turn default tag values into field values.
+// Parsers registered for an exact reflect.Type take precedence over the built-in
+// parsers keyed by reflect.Kind.
+type registry struct {
+	mu     sync.RWMutex
+	gen    uint64 // incremented on every change, to invalidate compiled plans
+	custom bool   // whether types differs from builtinTypes
+	types  map[reflect.Type]ParserFunc
+	kinds  map[reflect.Kind]ParserFunc
+}
+
+// builtinTypes holds the parsers registered for exact types out of the box.
+var builtinTypes = map[reflect.Type]ParserFunc{
+	reflect.TypeFor[time.Duration]():  ParseDuration,
+	reflect.TypeFor[time.Time]():      ParseTime,
+	reflect.TypeFor[*time.Location](): ParseLocation,
+	reflect.TypeFor[time.Month]():     ParseMonth,
+	reflect.TypeFor[time.Weekday]():   ParseWeekday,
+	reflect.TypeFor[ByteSize]():       ParseQuantity,
+}
+
+// parsers is the package-level registry consulted by Defaults.
+var parsers = &registry{types: maps.Clone(builtinTypes)}
+
+func init() {
+	// The JSON parsers look up durations in the registry, so their kinds are
+	// registered once it exists.
+	parsers.kinds = map[reflect.Kind]ParserFunc{
+		reflect.Int:        ParseInt,
+		reflect.Int8:       ParseInt,
+		reflect.Int16:      ParseInt,
+		reflect.Int32:      ParseInt,
+		reflect.Int64:      ParseInt,
+		reflect.Uint:       ParseUint,
+		reflect.Uint8:      ParseUint,
+		reflect.Uint16:     ParseUint,
+		reflect.Uint32:     ParseUint,
+		reflect.Uint64:     ParseUint,
+		reflect.Float32:    ParseFloat,
+		reflect.Float64:    ParseFloat,
+		reflect.Complex64:  ParseComplex,
+		reflect.Complex128: ParseComplex,
+		reflect.Bool:       ParseBool,
+		reflect.String:     ParseString,
+		reflect.Map:        ParseMap,
+		reflect.Slice:      ParseSlice,
+		reflect.Array:      ParseArray,
+		reflect.Struct:     ParseStruct,
+	}
+}
+
+// RegisterParser registers fn as the parser for fields of exactly type t,
+// replacing any parser previously registered for t. Registered parsers take
+// precedence over the built-in parsers selected by the field's kind.
+//
+// It panics if t or fn is nil.
+func RegisterParser(t reflect.Type, fn ParserFunc) {
+	if t == nil {
+		panic("defaults: RegisterParser called with nil type")
+	}
+	if fn == nil {
+		panic("defaults: RegisterParser called with nil parser")
+	}
+	parsers.mu.Lock()
+	defer parsers.mu.Unlock()
+	parsers.types[t] = fn
+	parsers.changed()
+}
+
+// RegisterParserFor registers fn as the parser for fields of type T.
+//
+// It is a typed convenience wrapper around RegisterParser:
+//
+//	defaults.RegisterParserFor(func(s string) (*url.URL, error) {
+//		return url.Parse(s)
+//	})
+func RegisterParserFor[T any](fn func(string) (T, error)) {
+	if fn == nil {
+		panic("defaults: RegisterParserFor called with nil parser")
+	}
+	RegisterParser(reflect.TypeFor[T](), func(str string, _ reflect.Type) (reflect.Value, error) {
+		val, err := fn(str)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&val).Elem(), nil
+	})
+}
+
+// UnregisterParser removes the parser registered for type t, if any.
+// It reports whether a parser was removed. Built-in kind parsers are not affected.
+func UnregisterParser(t reflect.Type) bool {
+	parsers.mu.Lock()
+	defer parsers.mu.Unlock()
+	if _, ok := parsers.types[t]; !ok {
+		return false
+	}
+	delete(parsers.types, t)
+	parsers.changed()
+	return true
+}
+
+// changed records a change to the registered parsers. It must be called with r.mu held.
+func (r *registry) changed() {
+	r.gen++
+	r.custom = len(r.types) != len(builtinTypes)
+	for t, fn := range builtinTypes {
+		if !sameFunc(r.types[t], fn) {
+			r.custom = true
+		}
+	}
+}
+
+// isBuiltin reports whether only the built-in parsers are registered.
+func (r *registry) isBuiltin() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.custom
+}
+
+// RegisteredParsers returns the types that currently have a registered parser,
+// sorted by their string representation.
+func RegisteredParsers() []reflect.Type {
+	parsers.mu.RLock()
+	defer parsers.mu.RUnlock()
+	types := make([]reflect.Type, 0, len(parsers.types))
+	for t := range parsers.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+	return types
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// lookup returns the parser for type t. A parser registered for the exact type
+// wins, followed by ParseText for types implementing encoding.TextUnmarshaler
+// and ParseJSON for types implementing json.Unmarshaler. Otherwise the built-in
+// parser for the kind of t is used, so named types such as `type Port int` are
+// parsed like their underlying type.
+func (r *registry) lookup(t reflect.Type) (ParserFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if fn, ok := r.types[t]; ok {
+		return fn, true
+	}
+	if implements(t, textUnmarshalerType) {
+		return ParseText, true
+	}
+	if implements(t, jsonUnmarshalerType) {
+		return ParseJSON, true
+	}
+	fn, ok := r.kinds[t.Kind()]
+	return fn, ok
+}
+
+// generation returns a counter that changes whenever a parser is registered or unregistered.
+func (r *registry) generation() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.gen
+}
+
+// hasCustom reports whether t is parsed by a registered parser or by its own
+// UnmarshalText or UnmarshalJSON method rather than by a built-in kind parser.
+func (r *registry) hasCustom(t reflect.Type) bool {
+	return r.hasType(t) || implements(t, textUnmarshalerType) || implements(t, jsonUnmarshalerType)
+}
+
+// implements reports whether the non-pointer type t or its pointer implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface &&
+		reflect.PointerTo(t).Implements(iface)
+}
+
+// hasType reports whether a parser is registered for exactly type t.
+func (r *registry) hasType(t reflect.Type) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.types[t]
+	return ok
+}
@@ -0,0 +1,148 @@
+package defaults
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCelsius struct {
+	Degrees float64
+}
+
+type testRegistered struct {
+	IP       net.IP      `default:"10.0.0.1"`
+	URL      *url.URL    `default:"https://example.com/path"`
+	Celsius  testCelsius `default:"21.5C"`
+	Untagged testCelsius
+}
+
+func parseCelsius(str string) (testCelsius, error) {
+	if !strings.HasSuffix(str, "C") {
+		return testCelsius{}, errors.New("missing unit")
+	}
+	val, err := ParseFloat(strings.TrimSuffix(str, "C"), reflect.TypeFor[float64]())
+	if err != nil {
+		return testCelsius{}, err
+	}
+	return testCelsius{Degrees: val.Float()}, nil
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser(reflect.TypeFor[net.IP](), func(str string, _ reflect.Type) (reflect.Value, error) {
+		ip := net.ParseIP(str)
+		if ip == nil {
+			return reflect.Value{}, errors.New("invalid IP address")
+		}
+		return reflect.ValueOf(ip), nil
+	})
+	RegisterParserFor(url.Parse)
+	RegisterParserFor(parseCelsius)
+	t.Cleanup(func() {
+		UnregisterParser(reflect.TypeFor[net.IP]())
+		UnregisterParser(reflect.TypeFor[*url.URL]())
+		UnregisterParser(reflect.TypeFor[testCelsius]())
+	})
+
+	var got testRegistered
+	if err := Defaults(&got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if !got.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("IP got %v, want 10.0.0.1", got.IP)
+	}
+	if got.URL == nil || got.URL.String() != "https://example.com/path" {
+		t.Errorf("URL got %v, want https://example.com/path", got.URL)
+	}
+	if got.Celsius.Degrees != 21.5 {
+		t.Errorf("Celsius got %v, want 21.5", got.Celsius.Degrees)
+	}
+	if got.Untagged.Degrees != 0 {
+		t.Errorf("Untagged got %v, want 0", got.Untagged.Degrees)
+	}
+}
+
+func TestRegisterParserError(t *testing.T) {
+	RegisterParserFor(parseCelsius)
+	t.Cleanup(func() { UnregisterParser(reflect.TypeFor[testCelsius]()) })
+
+	var got struct {
+		Celsius testCelsius `default:"21.5F"`
+	}
+	err := Defaults(&got)
+	if err == nil || !strings.Contains(err.Error(), "missing unit") {
+		t.Errorf("Defaults() error = %v, expected to contain %q", err, "missing unit")
+	}
+}
+
+func TestUnregisterParser(t *testing.T) {
+	typ := reflect.TypeFor[testCelsius]()
+	RegisterParserFor(parseCelsius)
+	if !UnregisterParser(typ) {
+		t.Errorf("UnregisterParser() = false, want true")
+	}
+	if UnregisterParser(typ) {
+		t.Errorf("UnregisterParser() on missing parser = true, want false")
+	}
+
+	var got struct {
+		Celsius *testCelsius `default:"21.5C"`
+	}
+	err := Defaults(&got)
+	if err == nil || !strings.Contains(err.Error(), "invalid struct format") {
+		t.Errorf("Defaults() error = %v, expected to contain %q", err, "invalid struct format")
+	}
+}
+
+func TestRegisteredParsers(t *testing.T) {
+	RegisterParserFor(parseCelsius)
+	t.Cleanup(func() { UnregisterParser(reflect.TypeFor[testCelsius]()) })
+
+	got := RegisteredParsers()
+	want := []reflect.Type{
+		reflect.TypeFor[*time.Location](),
+		reflect.TypeFor[ByteSize](),
+		reflect.TypeFor[testCelsius](),
+		reflect.TypeFor[time.Duration](),
+		reflect.TypeFor[time.Month](),
+		reflect.TypeFor[time.Time](),
+		reflect.TypeFor[time.Weekday](),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RegisteredParsers() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterParserPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "nil type",
+			fn:   func() { RegisterParser(nil, ParseString) },
+		},
+		{
+			name: "nil parser",
+			fn:   func() { RegisterParser(reflect.TypeFor[string](), nil) },
+		},
+		{
+			name: "nil typed parser",
+			fn:   func() { RegisterParserFor[string](nil) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package defaults
+
+import "reflect"
+
+// Setter is implemented by structs computing defaults that cannot be expressed
+// as tag values, such as a number of workers derived from runtime.NumCPU:
+//
+//	func (c *Config) SetDefaults() {
+//		if c.Workers == 0 {
+//			c.Workers = runtime.NumCPU() * 2
+//		}
+//	}
+//
+// Defaults calls SetDefaults on the root struct and on every nested struct or
+// struct pointer it processes, after the tag defaults of the struct and of all
+// the structs nested in it were applied. Nested structs are thus called before
+// the structs containing them, and the root struct last.
+//
+// A struct with a SetDefaults method always counts as having received defaults,
+// since Defaults cannot tell what the method did: a nil pointer to it under
+// AllocIfDefaulted is kept with what the method set, and a struct map value is
+// stored back.
+//
+// Methods generated by defaults-gen are not called as setters; see Generated.
+type Setter interface {
+	SetDefaults()
+}
+
+// ErrorSetter is like Setter for SetDefaults methods that can fail.
+// A non-nil error aborts Defaults and is returned as a *FieldError
+// holding the path of the struct.
+type ErrorSetter interface {
+	SetDefaults() error
+}
+
+// setterKind tells which setter interface, if any, a struct implements.
+type setterKind int
+
+const (
+	setterNone setterKind = iota
+	setterPlain
+	setterError
+)
+
+var (
+	setterType      = reflect.TypeFor[Setter]()
+	errorSetterType = reflect.TypeFor[ErrorSetter]()
+	generatedType   = reflect.TypeFor[Generated]()
+)
+
+// setterKindFor returns the setter interface implemented by pointers to struct type t.
+func setterKindFor(t reflect.Type) setterKind {
+	ptr := reflect.PointerTo(t)
+	switch {
+	case ptr.Implements(generatedType):
+		return setterNone
+	case ptr.Implements(setterType):
+		return setterPlain
+	case ptr.Implements(errorSetterType):
+		return setterError
+	default:
+		return setterNone
+	}
+}
+
+// callSetter calls the SetDefaults method of the addressable struct v, if any.
+func callSetter(kind setterKind, v reflect.Value) error {
+	switch kind {
+	case setterPlain:
+		v.Addr().Interface().(Setter).SetDefaults()
+	case setterError:
+		return v.Addr().Interface().(ErrorSetter).SetDefaults()
+	}
+	return nil
+}
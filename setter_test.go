@@ -0,0 +1,198 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setterCalls records the order in which SetDefaults methods are called
+var setterCalls []string
+
+// Structs for testing SetDefaults methods
+type testSetterLeaf struct {
+	Size  int `default:"2"`
+	Total int
+}
+
+func (l *testSetterLeaf) SetDefaults() {
+	setterCalls = append(setterCalls, "leaf")
+	if l.Total == 0 {
+		l.Total = l.Size * 10
+	}
+}
+
+type testSetterMiddle struct {
+	Leaf    testSetterLeaf
+	LeafPtr *testSetterLeaf
+}
+
+func (m *testSetterMiddle) SetDefaults() error {
+	setterCalls = append(setterCalls, "middle")
+	return nil
+}
+
+type testSetterRoot struct {
+	Name    string `default:"root"`
+	Middle  testSetterMiddle
+	Workers int
+}
+
+func (r *testSetterRoot) SetDefaults() {
+	setterCalls = append(setterCalls, "root")
+	if r.Workers == 0 {
+		r.Workers = r.Middle.Leaf.Total + 1
+	}
+}
+
+type testSetterFailing struct {
+	Fail bool
+}
+
+func (f *testSetterFailing) SetDefaults() error {
+	if f.Fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+type testSetterParent struct {
+	Child testSetterFailing
+	Fail  bool
+}
+
+func (p *testSetterParent) SetDefaults() error {
+	if p.Fail {
+		return errors.New("parent boom")
+	}
+	return nil
+}
+
+func TestSetter(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     *testSetterRoot
+		want      *testSetterRoot
+		wantCalls []string
+	}{
+		{
+			name:  "children before parents",
+			input: &testSetterRoot{},
+			want: &testSetterRoot{
+				Name: "root",
+				Middle: testSetterMiddle{
+					Leaf:    testSetterLeaf{Size: 2, Total: 20},
+					LeafPtr: &testSetterLeaf{Size: 2, Total: 20},
+				},
+				Workers: 21,
+			},
+			wantCalls: []string{"leaf", "leaf", "middle", "root"},
+		},
+		{
+			name:  "computed from set values",
+			input: &testSetterRoot{Middle: testSetterMiddle{Leaf: testSetterLeaf{Size: 5}}, Workers: 3},
+			want: &testSetterRoot{
+				Name: "root",
+				Middle: testSetterMiddle{
+					Leaf:    testSetterLeaf{Size: 5, Total: 50},
+					LeafPtr: &testSetterLeaf{Size: 2, Total: 20},
+				},
+				Workers: 3,
+			},
+			wantCalls: []string{"leaf", "leaf", "middle", "root"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setterCalls = nil
+			if err := Defaults(tt.input); err != nil {
+				t.Fatalf("Defaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("Defaults() got = %+v, want %+v", tt.input, tt.want)
+			}
+			if !reflect.DeepEqual(setterCalls, tt.wantCalls) {
+				t.Errorf("SetDefaults calls = %v, want %v", setterCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// testSetterLazy has no tags, only a SetDefaults method
+type testSetterLazy struct {
+	W int
+}
+
+func (l *testSetterLazy) SetDefaults() {
+	if l.W == 0 {
+		l.W = 4
+	}
+}
+
+type testSetterHolder struct {
+	Lazy  *testSetterLazy `default:",alloc=defaulted"`
+	Items map[string]testSetterLazy
+}
+
+func TestSetterCountsAsDefaulted(t *testing.T) {
+	got := &testSetterHolder{Items: map[string]testSetterLazy{"a": {}}}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	want := &testSetterHolder{Lazy: &testSetterLazy{W: 4}, Items: map[string]testSetterLazy{"a": {W: 4}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetterError(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     *testSetterParent
+		wantPath  string
+		errString string
+	}{
+		{
+			name:      "nested",
+			input:     &testSetterParent{Child: testSetterFailing{Fail: true}},
+			wantPath:  "Child",
+			errString: "failed to set default for field Child: SetDefaults failed: boom",
+		},
+		{
+			name:      "root",
+			input:     &testSetterParent{Fail: true},
+			wantPath:  "",
+			errString: "failed to set defaults for defaults.testSetterParent: SetDefaults failed: parent boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Defaults() error = %v, want *FieldError", err)
+			}
+			if fe.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", fe.Path, tt.wantPath)
+			}
+			if err.Error() != tt.errString {
+				t.Errorf("Defaults() error = %q, want %q", err, tt.errString)
+			}
+		})
+	}
+}
+
+func TestSetterCollectErrors(t *testing.T) {
+	d := New(WithErrorPolicy(ErrorPolicy{Collect: true}))
+	err := d.Apply(&testSetterParent{Child: testSetterFailing{Fail: true}, Fail: true})
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("Apply() error = %v, want 2 collected errors", err)
+	}
+	if !strings.Contains(multi.Errors[0].Error(), "boom") || !strings.Contains(multi.Errors[1].Error(), "parent boom") {
+		t.Errorf("Apply() errors = %v, want child error before parent error", multi.Errors)
+	}
+}
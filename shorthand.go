@@ -0,0 +1,186 @@
+package defaults
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Syntax selects how slice, array and map defaults are written in tags.
+type Syntax int
+
+const (
+	// SyntaxJSON reads slices, arrays and maps as JSON, as in `default:"[\"a\",\"b\"]"`.
+	SyntaxJSON Syntax = iota
+	// SyntaxShort reads slices and arrays as comma-separated elements, as in
+	// `default:"a,b"`, and maps as comma-separated key:value pairs, as in
+	// `default:"a:1,b:2"`. Each key and element is parsed with the parser of its
+	// type, so a []time.Duration accepts `default:"1s,5s"`. Elements containing
+	// commas, colons or surrounding spaces are written as double-quoted Go strings,
+	// as in `default:"\"a,b\",c"`.
+	SyntaxShort
+)
+
+// SetSyntax sets how Defaults reads slice, array and map defaults.
+// Use WithSyntax to configure a Defaulter instead.
+// It can be overridden per field with the `syntax=json|short` tag option:
+//
+//	Tags []string `default:"a,b,c,syntax=short"`
+func SetSyntax(s Syntax) {
+	std.syntax = s
+}
+
+// parseSyntax parses the argument of the syntax tag option.
+func parseSyntax(arg string) (Syntax, error) {
+	switch arg {
+	case "json":
+		return SyntaxJSON, nil
+	case "short":
+		return SyntaxShort, nil
+	default:
+		return 0, fmt.Errorf("invalid tag option syntax=%s", arg)
+	}
+}
+
+// valueOptions holds the tag options affecting how a value is parsed.
+type valueOptions struct {
+	short    bool   // SyntaxShort for slices, arrays and maps
+	quantity bool   // quantity syntax for integers and floats, see ParseQuantity
+	layout   string // layout of time.Time values, see parseTime
+}
+
+// valueOptions returns the parsing options for a field with the given tag options.
+func (d *Defaulter) valueOptions(opts tagOptions) valueOptions {
+	short := d.syntax == SyntaxShort
+	if opts.hasSyntax {
+		short = opts.syntax == SyntaxShort
+	}
+	quantity := d.quantity
+	if opts.hasQuantity {
+		quantity = opts.quantity
+	}
+	return valueOptions{short: short, quantity: quantity, layout: opts.layout}
+}
+
+// isShort reports whether a value of type t is parsed with the shorthand syntax under vo.
+func (d *Defaulter) isShort(t reflect.Type, vo valueOptions) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return vo.short && !d.hasCustomParser(t)
+	default:
+		return false
+	}
+}
+
+// parseShort parses s into a slice, array or map of type t with the shorthand syntax.
+func (d *Defaulter) parseShort(t reflect.Type, s string, vo valueOptions) (reflect.Value, error) {
+	items, err := splitShort(s, ',')
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		v := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := d.parseElem(t.Elem(), item, v.Index(i), vo); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		return v, nil
+	case reflect.Array:
+		if len(items) > t.Len() {
+			return reflect.Value{}, fmt.Errorf("%w: array length %d exceeds capacity %d", ErrOutOfRange, len(items), t.Len())
+		}
+		v := reflect.New(t).Elem()
+		for i, item := range items {
+			if err := d.parseElem(t.Elem(), item, v.Index(i), vo); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		return v, nil
+	default:
+		v := reflect.MakeMapWithSize(t, len(items))
+		for _, item := range items {
+			parts, err := splitShort(item, ':')
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if len(parts) < 2 {
+				return reflect.Value{}, fmt.Errorf("%w: map entry %q is not of the form key:value", ErrSyntax, strings.TrimSpace(item))
+			}
+			// Only the first unquoted colon separates the key, so values may hold colons
+			key := reflect.New(t.Key()).Elem()
+			if err := d.parseElem(t.Key(), parts[0], key, vo); err != nil {
+				return reflect.Value{}, err
+			}
+			val := reflect.New(t.Elem()).Elem()
+			if err := d.parseElem(t.Elem(), item[len(parts[0])+1:], val, vo); err != nil {
+				return reflect.Value{}, err
+			}
+			v.SetMapIndex(key, val)
+		}
+		return v, nil
+	}
+}
+
+// parseElem parses a single shorthand element of type t into dst.
+func (d *Defaulter) parseElem(t reflect.Type, item string, dst reflect.Value, vo valueOptions) error {
+	base := t
+	if base.Kind() == reflect.Ptr && !d.hasType(base) {
+		base = base.Elem()
+	}
+	switch base.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Interface:
+		if !d.hasCustomParser(t) {
+			return fmt.Errorf("%w: element type %s has no shorthand syntax", ErrUnsupportedType, t)
+		}
+	}
+	s, err := unquoteShort(item)
+	if err != nil {
+		return err
+	}
+	elem := vo
+	elem.short = false
+	val, err := d.parseValue(t, s, elem)
+	if err != nil {
+		return err
+	}
+	dst.Set(val)
+	return nil
+}
+
+// splitShort splits s at the occurrences of sep outside double-quoted strings.
+func splitShort(s string, sep byte) ([]string, error) {
+	var items []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quoted element in %q", ErrSyntax, s)
+	}
+	return append(items, s[start:]), nil
+}
+
+// unquoteShort trims the spaces around a shorthand element and unquotes it
+// if it is a double-quoted Go string.
+func unquoteShort(item string) (string, error) {
+	item = strings.TrimSpace(item)
+	if !strings.HasPrefix(item, `"`) {
+		return item, nil
+	}
+	s, err := strconv.Unquote(item)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid quoted element %s", ErrSyntax, item)
+	}
+	return s, nil
+}
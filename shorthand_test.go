@@ -0,0 +1,155 @@
+package defaults
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testShort struct {
+	Tags     []string                  `default:"a, b ,c,syntax=short"`
+	Quoted   []string                  `default:"\"a,b\",\" c \",syntax=short"`
+	Waits    []time.Duration           `default:"1s,5s,syntax=short"`
+	Ports    [3]uint16                 `default:"80,443,syntax=short"`
+	Ptrs     []*int                    `default:"1,2,syntax=short"`
+	Addrs    []netip.Addr              `default:"10.0.0.1,::1,syntax=short"`
+	Limits   map[string]int            `default:"read:1, write:2,syntax=short"`
+	URLs     map[string]string         `default:"api:http://x:8080,\"a:b\":c,syntax=short"`
+	Timeouts map[int]time.Duration     `default:"1:1s,2:2m,syntax=short"`
+	PtrTags  *[]string                 `default:"x,y,syntax=short"`
+	OptTags  Opt[[]int]                `default:"1,2,syntax=short"`
+	JSON     []string                  `default:"[\"a,b\"],syntax=json"`
+	Nested   map[string]map[string]int `default:"{\"a\":{\"b\":1}}"`
+}
+
+func TestShortSyntax(t *testing.T) {
+	one, two := 1, 2
+	ptrTags := []string{"x", "y"}
+	want := &testShort{
+		Tags:     []string{"a", "b", "c"},
+		Quoted:   []string{"a,b", " c "},
+		Waits:    []time.Duration{time.Second, 5 * time.Second},
+		Ports:    [3]uint16{80, 443},
+		Ptrs:     []*int{&one, &two},
+		Addrs:    []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("::1")},
+		Limits:   map[string]int{"read": 1, "write": 2},
+		URLs:     map[string]string{"api": "http://x:8080", "a:b": "c"},
+		Timeouts: map[int]time.Duration{1: time.Second, 2: 2 * time.Minute},
+		PtrTags:  &ptrTags,
+		OptTags:  Some([]int{1, 2}),
+		JSON:     []string{"a,b"},
+		Nested:   map[string]map[string]int{"a": {"b": 1}},
+	}
+	got := &testShort{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() got = %+v, want %+v", got, want)
+	}
+}
+
+type testShortGlobal struct {
+	Tags   []string       `default:"a,b"`
+	Limits map[string]int `default:"a:1"`
+	JSON   []int          `default:"[1,2],syntax=json"`
+	Name   string         `default:"x,y"`
+}
+
+func TestWithSyntax(t *testing.T) {
+	want := &testShortGlobal{
+		Tags:   []string{"a", "b"},
+		Limits: map[string]int{"a": 1},
+		JSON:   []int{1, 2},
+		Name:   "x,y",
+	}
+	got := &testShortGlobal{}
+	if err := New(WithSyntax(SyntaxShort)).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+
+	// Plans compiled for one syntax are not reused for the other
+	t.Cleanup(func() { SetSyntax(SyntaxJSON) })
+	if err := Defaults(&testShortGlobal{}); err == nil {
+		t.Error("Defaults() error = nil, want JSON error")
+	}
+	SetSyntax(SyntaxShort)
+	got = &testShortGlobal{}
+	if err := Defaults(got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestShortSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		wantIs    error
+		errString string
+	}{
+		{
+			name: "element out of range",
+			input: &struct {
+				V []int8 `default:"1,300,syntax=short"`
+			}{},
+			wantIs:    ErrOutOfRange,
+			errString: "failed to set default for field V: strconv.ParseInt: parsing \"300\": value out of range",
+		},
+		{
+			name: "array too long",
+			input: &struct {
+				V [2]int `default:"1,2,3,syntax=short"`
+			}{},
+			wantIs:    ErrOutOfRange,
+			errString: "failed to set default for field V: value out of range: array length 3 exceeds capacity 2",
+		},
+		{
+			name: "unterminated quote",
+			input: &struct {
+				V []string `default:"\"a,b,syntax=short"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: "failed to set default for field V: invalid syntax: unterminated quoted element",
+		},
+		{
+			name: "missing colon",
+			input: &struct {
+				V map[string]int `default:"a:1,b,syntax=short"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid syntax: map entry "b" is not of the form key:value`,
+		},
+		{
+			name: "composite elements",
+			input: &struct {
+				V [][]int `default:"1,2,syntax=short"`
+			}{},
+			wantIs:    ErrUnsupportedType,
+			errString: "failed to set default for field V: unsupported type: element type []int has no shorthand syntax",
+		},
+		{
+			name: "invalid option",
+			input: &struct {
+				V []int `default:"1,syntax=csv"`
+			}{},
+			errString: "failed to set default for field V: invalid tag option syntax=csv",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errString) {
+				t.Errorf("Defaults() error = %v, want prefix %q", err, tt.errString)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+		})
+	}
+}
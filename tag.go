@@ -1,5 +1,12 @@
 package defaults
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
 const (
 	defaultTag = "default"
 )
@@ -11,3 +18,175 @@ var Tag = defaultTag
 func SetDefaultTag(tag string) {
 	Tag = tag
 }
+
+// ValidateTag checks that tag, the value of a default tag, is valid for a field of type t.
+// It parses the options and the value with the same rules as Defaults without setting
+// anything. Tags without a value, as on nested structs, are valid. Values with ${VAR}
+// and ${.Field} references are checked with their fallbacks, and not checked if a reference has none.
+// Provider calls such as @now are checked for syntax only.
+func ValidateTag(t reflect.Type, tag string) error {
+	return std.ValidateTag(t, tag)
+}
+
+// ValidateTag is like the package-level ValidateTag, using the Defaulter's parsers.
+func (d *Defaulter) ValidateTag(t reflect.Type, tag string) error {
+	value, opts, err := parseTag(tag, t)
+	if err != nil || value == "" {
+		return err
+	}
+	raw := value
+	if hasReferences(value) && !d.noInterp {
+		// Check the references, and the value obtained with the fallbacks unless
+		// it depends on variables without fallback
+		in := &interpolator{
+			lookup: func(string) (string, bool) { return "", false },
+			ref:    func(string) (string, bool, error) { return "", false, nil },
+		}
+		if value, err = in.expand(value); err != nil || in.missing {
+			return err
+		}
+	}
+	if isProviderCall(raw) {
+		// Providers may not be registered yet, and are not called when validating
+		_, _, err = parseProviderCall(value)
+		return err
+	}
+	_, err = d.parseDefault(t, raw, value, d.valueOptions(opts))
+	return err
+}
+
+// tagOptions holds the options that follow the default value in a tag,
+// as in `default:"value,noalloc"`.
+type tagOptions struct {
+	alloc       AllocPolicy
+	hasAlloc    bool
+	policy      ApplyPolicy
+	hasPolicy   bool
+	syntax      Syntax
+	hasSyntax   bool
+	layout      string
+	quantity    bool
+	hasQuantity bool
+}
+
+// parseTag splits a tag of a field of type t into its default value and trailing options.
+//
+// Options are recognized from the end of the tag only, so commas inside the
+// value (as in JSON literals) are left untouched. Options that only apply to some
+// types are only recognized on fields of those types, and are part of the value
+// elsewhere: quantity on integers and floats, layout on time.Time values and
+// syntax on slices, arrays and maps.
+func parseTag(tag string, t reflect.Type) (string, tagOptions, error) {
+	var opts tagOptions
+	for {
+		i := strings.LastIndexByte(tag, ',')
+		if i < 0 {
+			return tag, opts, nil
+		}
+		// Options are scanned right to left, so the last occurrence wins
+		name, arg, hasArg := strings.Cut(tag[i+1:], "=")
+		switch {
+		case name == "noalloc" && !hasArg:
+			if !opts.hasAlloc {
+				opts.alloc, opts.hasAlloc = AllocNever, true
+			}
+		case name == "alloc" && hasArg:
+			alloc, err := parseAllocPolicy(arg)
+			if err != nil {
+				return "", opts, err
+			}
+			if !opts.hasAlloc {
+				opts.alloc, opts.hasAlloc = alloc, true
+			}
+		case name == "policy" && hasArg:
+			policy, err := parseApplyPolicy(arg)
+			if err != nil {
+				return "", opts, err
+			}
+			if !opts.hasPolicy {
+				opts.policy, opts.hasPolicy = policy, true
+			}
+		case name == "syntax" && hasArg && acceptsSyntax(t):
+			syntax, err := parseSyntax(arg)
+			if err != nil {
+				return "", opts, err
+			}
+			if !opts.hasSyntax {
+				opts.syntax, opts.hasSyntax = syntax, true
+			}
+		case name == "quantity" && acceptsQuantity(t):
+			quantity := true
+			if hasArg {
+				var err error
+				if quantity, err = strconv.ParseBool(arg); err != nil {
+					return "", opts, fmt.Errorf("invalid tag option quantity=%s", arg)
+				}
+			}
+			if !opts.hasQuantity {
+				opts.quantity, opts.hasQuantity = quantity, true
+			}
+		case name == "layout" && hasArg && acceptsLayout(t):
+			if arg == "" {
+				return "", opts, fmt.Errorf("invalid tag option layout=%s", arg)
+			}
+			if opts.layout == "" {
+				opts.layout = arg
+			}
+		default:
+			if acceptsLayout(t) && strings.Contains(tag, ",layout=") {
+				// The scan stopped inside the layout
+				return "", opts, fmt.Errorf("invalid tag option layout: layouts cannot contain commas")
+			}
+			return tag, opts, nil
+		}
+		tag = tag[:i]
+	}
+}
+
+// holds reports whether fields of type t hold values of a type accepted by fn,
+// directly or as the elements of pointers, Opt values, slices, arrays and maps.
+func holds(t reflect.Type, fn func(reflect.Type) bool) bool {
+	for {
+		if opt, ok := reflect.New(t).Interface().(optional); ok {
+			t = opt.valueType()
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return fn(t)
+		}
+	}
+}
+
+// acceptsSyntax reports whether fields of type t are slices, arrays or maps, or
+// pointers or Opt values holding them, which the syntax option applies to.
+func acceptsSyntax(t reflect.Type) bool {
+	switch jsonElem(t).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// acceptsLayout reports whether fields of type t hold time.Time values, which the
+// layout option applies to (see holds).
+func acceptsLayout(t reflect.Type) bool {
+	return holds(t, func(t reflect.Type) bool { return t == timeType })
+}
+
+// parseAllocPolicy parses the argument of the alloc tag option.
+func parseAllocPolicy(arg string) (AllocPolicy, error) {
+	switch arg {
+	case "always":
+		return AllocAlways, nil
+	case "defaulted":
+		return AllocIfDefaulted, nil
+	case "never":
+		return AllocNever, nil
+	default:
+		return 0, fmt.Errorf("invalid tag option alloc=%s", arg)
+	}
+}
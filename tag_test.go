@@ -1,6 +1,11 @@
 package defaults
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestSetTag(t *testing.T) {
 	tests := []struct {
@@ -14,6 +19,7 @@ func TestSetTag(t *testing.T) {
 			want:  "default 2",
 		},
 	}
+	t.Cleanup(func() { SetDefaultTag(defaultTag) })
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			SetDefaultTag(tt.input)
@@ -23,3 +29,331 @@ func TestSetTag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		typ       reflect.Type // int if nil
+		wantVal   string
+		wantOpts  tagOptions
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:    "plain value",
+			input:   "8080",
+			wantVal: "8080",
+		},
+		{
+			name:    "JSON value with commas",
+			input:   `{"a":1,"b":2}`,
+			wantVal: `{"a":1,"b":2}`,
+		},
+		{
+			name:     "options only",
+			input:    ",noalloc",
+			wantVal:  "",
+			wantOpts: tagOptions{alloc: AllocNever, hasAlloc: true},
+		},
+		{
+			name:     "value and option",
+			input:    `[1,2,3],alloc=defaulted`,
+			wantVal:  `[1,2,3]`,
+			wantOpts: tagOptions{alloc: AllocIfDefaulted, hasAlloc: true},
+		},
+		{
+			name:     "last option wins",
+			input:    ",alloc=never,alloc=always",
+			wantVal:  "",
+			wantOpts: tagOptions{alloc: AllocAlways, hasAlloc: true},
+		},
+		{
+			name:     "policy option",
+			input:    "8080,policy=always",
+			wantVal:  "8080",
+			wantOpts: tagOptions{policy: ApplyAlways, hasPolicy: true},
+		},
+		{
+			name:     "policy and alloc options",
+			input:    ",noalloc,policy=never",
+			wantVal:  "",
+			wantOpts: tagOptions{alloc: AllocNever, hasAlloc: true, policy: ApplyNever, hasPolicy: true},
+		},
+		{
+			name:     "syntax option after shorthand value",
+			input:    "a,b,syntax=short",
+			typ:      reflect.TypeFor[[]string](),
+			wantVal:  "a,b",
+			wantOpts: tagOptions{syntax: SyntaxShort, hasSyntax: true},
+		},
+		{
+			name:     "layout option",
+			input:    "2024-01-01,layout=2006-01-02",
+			typ:      reflect.TypeFor[time.Time](),
+			wantVal:  "2024-01-01",
+			wantOpts: tagOptions{layout: "2006-01-02"},
+		},
+		{
+			name:     "layout option on Opt of time pointer",
+			input:    "01/02/2024,layout=01/02/2006",
+			typ:      reflect.TypeFor[Opt[*time.Time]](),
+			wantVal:  "01/02/2024",
+			wantOpts: tagOptions{layout: "01/02/2006"},
+		},
+		{
+			name:    "layout option on string is part of the value",
+			input:   "foo,layout=bar",
+			typ:     reflect.TypeFor[string](),
+			wantVal: "foo,layout=bar",
+		},
+		{
+			name:      "layout containing a comma",
+			input:     "Jan 2 2024,layout=Jan 2, 2006",
+			typ:       reflect.TypeFor[time.Time](),
+			wantErr:   true,
+			errString: "invalid tag option layout: layouts cannot contain commas",
+		},
+		{
+			name:     "syntax option on map pointer",
+			input:    "a:1,syntax=short",
+			typ:      reflect.TypeFor[*map[string]int](),
+			wantVal:  "a:1",
+			wantOpts: tagOptions{syntax: SyntaxShort, hasSyntax: true},
+		},
+		{
+			name:    "syntax option on string is part of the value",
+			input:   "a,syntax=short",
+			typ:     reflect.TypeFor[string](),
+			wantVal: "a,syntax=short",
+		},
+		{
+			name:     "quantity option",
+			input:    "64MiB,quantity",
+			wantVal:  "64MiB",
+			wantOpts: tagOptions{quantity: true, hasQuantity: true},
+		},
+		{
+			name:     "quantity option disabled",
+			input:    "0x10,quantity=false",
+			wantVal:  "0x10",
+			wantOpts: tagOptions{hasQuantity: true},
+		},
+		{
+			name:    "quantity option on string is part of the value",
+			input:   "hello,quantity",
+			typ:     reflect.TypeFor[string](),
+			wantVal: "hello,quantity",
+		},
+		{
+			name:    "quantity option on slice of strings is part of the value",
+			input:   "a,quantity=x",
+			typ:     reflect.TypeFor[[]string](),
+			wantVal: "a,quantity=x",
+		},
+		{
+			name:     "quantity option on ByteSize pointer",
+			input:    "1k,quantity=false",
+			typ:      reflect.TypeFor[*ByteSize](),
+			wantVal:  "1k",
+			wantOpts: tagOptions{hasQuantity: true},
+		},
+		{
+			name:      "invalid quantity option",
+			input:     "1k,quantity=maybe",
+			wantErr:   true,
+			errString: "invalid tag option quantity=maybe",
+		},
+		{
+			name:      "empty layout",
+			input:     "2024-01-01,layout=",
+			typ:       reflect.TypeFor[time.Time](),
+			wantErr:   true,
+			errString: "invalid tag option layout=",
+		},
+		{
+			name:      "invalid policy",
+			input:     "1,policy=sometimes",
+			wantErr:   true,
+			errString: "invalid tag option policy=sometimes",
+		},
+		{
+			name:    "unknown option is part of the value",
+			input:   "a,b",
+			wantVal: "a,b",
+		},
+		{
+			name:      "invalid option argument",
+			input:     ",alloc=sometimes",
+			wantErr:   true,
+			errString: "invalid tag option alloc=sometimes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := tt.typ
+			if typ == nil {
+				typ = reflect.TypeFor[int]()
+			}
+			gotVal, gotOpts, err := parseTag(tt.input, typ)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if err.Error() != tt.errString {
+					t.Errorf("parseTag() error = %v, want %q", err, tt.errString)
+				}
+				return
+			}
+			if gotVal != tt.wantVal || gotOpts != tt.wantOpts {
+				t.Errorf("parseTag() = %q, %+v, want %q, %+v", gotVal, gotOpts, tt.wantVal, tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     reflect.Type
+		tag       string
+		wantErr   bool
+		errString string
+	}{
+		{
+			name:  "valid int",
+			input: reflect.TypeFor[int8](),
+			tag:   "100",
+		},
+		{
+			name:      "overflow",
+			input:     reflect.TypeFor[int8](),
+			tag:       "300",
+			wantErr:   true,
+			errString: "value out of range",
+		},
+		{
+			name:      "invalid JSON",
+			input:     reflect.TypeFor[[]string](),
+			tag:       `["a",`,
+			wantErr:   true,
+			errString: "invalid slice format",
+		},
+		{
+			name:      "unsupported type",
+			input:     reflect.TypeFor[chan int](),
+			tag:       "1",
+			wantErr:   true,
+			errString: `unsupported type "chan"`,
+		},
+		{
+			name:      "invalid option",
+			input:     reflect.TypeFor[*testServer](),
+			tag:       ",alloc=sometimes",
+			wantErr:   true,
+			errString: "invalid tag option alloc=sometimes",
+		},
+		{
+			name:  "options only",
+			input: reflect.TypeFor[*testServer](),
+			tag:   ",noalloc",
+		},
+		{
+			name:  "JSON struct with option",
+			input: reflect.TypeFor[*testServer](),
+			tag:   `{"Port":1},noalloc`,
+		},
+		{
+			name:  "time with layout",
+			input: reflect.TypeFor[time.Time](),
+			tag:   "01/02/2024,layout=01/02/2006",
+		},
+		{
+			name:      "time not matching layout",
+			input:     reflect.TypeFor[time.Time](),
+			tag:       "2024-01-02,layout=01/02/2006",
+			wantErr:   true,
+			errString: "invalid syntax",
+		},
+		{
+			name:  "quantity",
+			input: reflect.TypeFor[int64](),
+			tag:   "64MiB,quantity",
+		},
+		{
+			name:      "quantity overflow",
+			input:     reflect.TypeFor[uint16](),
+			tag:       "64Ki,quantity",
+			wantErr:   true,
+			errString: "value out of range",
+		},
+		{
+			name:  "variable without fallback",
+			input: reflect.TypeFor[int](),
+			tag:   "${PORT}",
+		},
+		{
+			name:  "valid fallback",
+			input: reflect.TypeFor[int](),
+			tag:   "${PORT:-8080}",
+		},
+		{
+			name:      "invalid fallback",
+			input:     reflect.TypeFor[int](),
+			tag:       "${PORT:-http}",
+			wantErr:   true,
+			errString: "invalid syntax",
+		},
+		{
+			name:  "field reference",
+			input: reflect.TypeFor[string](),
+			tag:   "http://${.Host}:${..Port}",
+		},
+		{
+			name:      "invalid field reference fallback",
+			input:     reflect.TypeFor[int](),
+			tag:       "${.Port:-http}",
+			wantErr:   true,
+			errString: "invalid syntax",
+		},
+		{
+			name:  "provider",
+			input: reflect.TypeFor[int](),
+			tag:   "@custom(a, b)",
+		},
+		{
+			name:      "invalid provider call",
+			input:     reflect.TypeFor[string](),
+			tag:       "@env(HOME",
+			wantErr:   true,
+			errString: "invalid provider call",
+		},
+		{
+			name:      "escaped at sign",
+			input:     reflect.TypeFor[int](),
+			tag:       "@@1",
+			wantErr:   true,
+			errString: "invalid syntax",
+		},
+		{
+			name:      "unterminated reference",
+			input:     reflect.TypeFor[string](),
+			tag:       "${HOME",
+			wantErr:   true,
+			errString: "unterminated variable reference",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTag(tt.input, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errString) {
+				t.Errorf("ValidateTag() error = %v, expected to contain %q", err, tt.errString)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package defaults
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// SetClock sets the function used by Defaults to resolve relative time.Time defaults
+// such as `default:"now+24h"` and the @now provider. A nil now restores time.Now.
+// Use WithClock to configure a Defaulter instead.
+func SetClock(now func() time.Time) {
+	std.clock = now
+}
+
+// WithClock sets the function resolving relative time.Time defaults such as
+// `default:"now+24h"` and the @now provider, e.g. a fixed time in tests. A nil
+// now restores time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(d *Defaulter) {
+		d.clock = now
+	}
+}
+
+// ParseTime parses a string to a time.Time. It accepts RFC 3339 times such as
+// 2024-01-02T15:04:05Z and times relative to time.Now: now, now+7d or now-1h30m,
+// with the durations of ParseDuration.
+//
+// Fields parsed by Defaults also honor the layout tag option and the Defaulter's
+// clock (see WithClock):
+//
+//	Start time.Time `default:"2024-01-01,layout=2006-01-02"`
+func ParseTime(str string, t reflect.Type) (reflect.Value, error) {
+	val, err := parseTime(str, "", time.Now)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(val).Convert(t), nil
+}
+
+// now returns the current time of the Defaulter's clock.
+func (d *Defaulter) now() time.Time {
+	if d.clock == nil {
+		return time.Now()
+	}
+	return d.clock()
+}
+
+// parseTime parses the time.Time default of a field with the layout tag option.
+func (d *Defaulter) parseTime(str, layout string) (reflect.Value, error) {
+	val, err := parseTime(str, layout, d.now)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(val), nil
+}
+
+// parseTime parses str as now[±duration] or, failing that, with layout,
+// which defaults to time.RFC3339.
+func parseTime(str, layout string, now func() time.Time) (time.Time, error) {
+	if rest, ok := strings.CutPrefix(str, "now"); ok {
+		if rest == "" {
+			return now(), nil
+		}
+		if rest[0] == '+' || rest[0] == '-' {
+			offset, err := parseDuration(rest)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return now().Add(offset), nil
+		}
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	val, err := time.Parse(layout, str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+	return val, nil
+}
+
+// ParseLocation parses an IANA time zone name such as America/New_York to a
+// *time.Location with time.LoadLocation. "UTC" and "Local" are also accepted.
+func ParseLocation(str string, t reflect.Type) (reflect.Value, error) {
+	if str == "" {
+		// LoadLocation returns UTC for an empty name, which would hide a missing value
+		return reflect.Value{}, fmt.Errorf("%w: empty time zone name", ErrSyntax)
+	}
+	loc, err := time.LoadLocation(str)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %w", ErrSyntax, err)
+	}
+	return reflect.ValueOf(loc).Convert(t), nil
+}
+
+// ParseMonth parses a string to a time.Month. It accepts English month names,
+// in full or abbreviated to three letters and in any case, and the numbers 1 to 12.
+func ParseMonth(str string, t reflect.Type) (reflect.Value, error) {
+	for m := time.January; m <= time.December; m++ {
+		if matchName(str, m.String()) {
+			return reflect.ValueOf(m).Convert(t), nil
+		}
+	}
+	n, err := parseCalendarNumber(str, int(time.January), int(time.December))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("month %q: %w", str, err)
+	}
+	return reflect.ValueOf(time.Month(n)).Convert(t), nil
+}
+
+// ParseWeekday parses a string to a time.Weekday. It accepts English day names,
+// in full or abbreviated to three letters and in any case, and the numbers 0
+// (Sunday) to 6.
+func ParseWeekday(str string, t reflect.Type) (reflect.Value, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if matchName(str, d.String()) {
+			return reflect.ValueOf(d).Convert(t), nil
+		}
+	}
+	n, err := parseCalendarNumber(str, int(time.Sunday), int(time.Saturday))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("weekday %q: %w", str, err)
+	}
+	return reflect.ValueOf(time.Weekday(n)).Convert(t), nil
+}
+
+// matchName reports whether str is name or its three-letter abbreviation, ignoring case.
+func matchName(str, name string) bool {
+	return strings.EqualFold(str, name) || len(str) == 3 && strings.EqualFold(str, name[:3])
+}
+
+// parseCalendarNumber parses the number of a month or weekday in [lo, hi].
+func parseCalendarNumber(str string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, ErrSyntax
+	}
+	if n < lo || n > hi {
+		return 0, fmt.Errorf("%w: must be between %d and %d", ErrOutOfRange, lo, hi)
+	}
+	return n, nil
+}
+
+// sameFunc reports whether fn is the parser or provider function builtin.
+func sameFunc[F ParserFunc | ProviderFunc](fn, builtin F) bool {
+	return reflect.ValueOf(fn).Pointer() == reflect.ValueOf(builtin).Pointer()
+}
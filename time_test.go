@@ -0,0 +1,201 @@
+package defaults
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testTimes struct {
+	Created  time.Time      `default:"2024-05-06T07:08:09Z"`
+	Day      time.Time      `default:"2024-05-06,layout=2006-01-02"`
+	Started  time.Time      `default:"now"`
+	Expires  *time.Time     `default:"now+24h"`
+	Earlier  Opt[time.Time] `default:"now-1h30m"`
+	Holidays []time.Time    `default:"2024-12-25, 2024-12-26,syntax=short,layout=2006-01-02"`
+	Zone     *time.Location `default:"UTC"`
+	Local    *time.Location `default:"Local"`
+	Month    time.Month     `default:"march"`
+	Months   []time.Month   `default:"Jan,12,syntax=short"`
+	Weekday  time.Weekday   `default:"Saturday"`
+	Days     []time.Weekday `default:"mon,0,syntax=short"`
+}
+
+func TestTimeDefaults(t *testing.T) {
+	got := &testTimes{}
+	if err := New(WithClock(func() time.Time { return testNow })).Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	expires := testNow.Add(24 * time.Hour)
+	want := &testTimes{
+		Created: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		Day:     time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Started: testNow,
+		Expires: &expires,
+		Earlier: Some(testNow.Add(-90 * time.Minute)),
+		Holidays: []time.Time{
+			time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC),
+		},
+		Zone:    time.UTC,
+		Local:   time.Local,
+		Month:   time.March,
+		Months:  []time.Month{time.January, time.December},
+		Weekday: time.Saturday,
+		Days:    []time.Weekday{time.Monday, time.Sunday},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	t.Cleanup(func() { SetClock(nil) })
+	SetClock(func() time.Time { return testNow })
+	got := &struct {
+		Deadline time.Time `default:"now+1m"`
+	}{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if want := testNow.Add(time.Minute); !got.Deadline.Equal(want) {
+		t.Errorf("Deadline = %v, want %v", got.Deadline, want)
+	}
+
+	// Relative times are resolved on every call
+	SetClock(nil)
+	before := time.Now()
+	got.Deadline = time.Time{}
+	if err := Defaults(got); err != nil {
+		t.Fatalf("Defaults() error = %v", err)
+	}
+	if got.Deadline.Before(before.Add(time.Minute)) {
+		t.Errorf("Deadline = %v, want after %v", got.Deadline, before.Add(time.Minute))
+	}
+}
+
+func TestTimeLayoutWithCustomParser(t *testing.T) {
+	d := New(WithParser(reflect.TypeFor[time.Time](), func(str string, t reflect.Type) (reflect.Value, error) {
+		return reflect.ValueOf(testNow), nil
+	}))
+	got := &struct {
+		Start time.Time `default:"tomorrow,layout=2006-01-02"`
+	}{}
+	if err := d.Apply(got); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !got.Start.Equal(testNow) {
+		t.Errorf("Start = %v, want %v", got.Start, testNow)
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantErr   bool
+		errString string
+	}{
+		{name: "IANA name", input: "America/New_York", want: "America/New_York"},
+		{name: "UTC", input: "UTC", want: "UTC"},
+		{name: "unknown zone", input: "Mars/Olympus", wantErr: true, errString: "invalid syntax: unknown time zone Mars/Olympus"},
+		{name: "empty", input: "", wantErr: true, errString: "invalid syntax: empty time zone name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLocation(tt.input, reflect.TypeFor[*time.Location]())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if err.Error() != tt.errString {
+					t.Errorf("ParseLocation() error = %q, want %q", err, tt.errString)
+				}
+				return
+			}
+			if loc := got.Interface().(*time.Location); loc.String() != tt.want {
+				t.Errorf("ParseLocation() = %v, want %v", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     any
+		wantIs    error
+		errString string
+	}{
+		{
+			name: "not RFC 3339",
+			input: &struct {
+				V time.Time `default:"2024-01-02"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid syntax: parsing time "2024-01-02"`,
+		},
+		{
+			name: "layout mismatch",
+			input: &struct {
+				V time.Time `default:"2024-01-02,layout=01/02/2006"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid syntax: parsing time "2024-01-02": month out of range`,
+		},
+		{
+			name: "invalid offset",
+			input: &struct {
+				V time.Time `default:"now+1 day"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: invalid syntax: time: unknown unit " day"`,
+		},
+		{
+			name: "unknown month",
+			input: &struct {
+				V time.Month `default:"Febuary"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: `failed to set default for field V: month "Febuary": invalid syntax`,
+		},
+		{
+			name: "month out of range",
+			input: &struct {
+				V time.Month `default:"13"`
+			}{},
+			wantIs:    ErrOutOfRange,
+			errString: `failed to set default for field V: month "13": value out of range: must be between 1 and 12`,
+		},
+		{
+			name: "weekday out of range",
+			input: &struct {
+				V time.Weekday `default:"7"`
+			}{},
+			wantIs:    ErrOutOfRange,
+			errString: `failed to set default for field V: weekday "7": value out of range: must be between 0 and 6`,
+		},
+		{
+			name: "unknown time zone",
+			input: &struct {
+				V *time.Location `default:"Mars/Olympus"`
+			}{},
+			wantIs:    ErrSyntax,
+			errString: "failed to set default for field V: invalid syntax: unknown time zone Mars/Olympus",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Defaults(tt.input)
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("Defaults() error = %v, want %v", err, tt.wantIs)
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errString) {
+				t.Errorf("Defaults() error = %v, want prefix %q", err, tt.errString)
+			}
+		})
+	}
+}
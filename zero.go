@@ -0,0 +1,75 @@
+package defaults
+
+import "reflect"
+
+// EmptyMode controls when a map or slice field counts as unset.
+type EmptyMode int
+
+const (
+	// EmptyNil treats only nil maps and slices as unset, so an empty non-nil
+	// collection is kept as an intentional value.
+	EmptyNil EmptyMode = iota
+	// EmptyLen treats maps and slices of length zero as unset, whether nil or not.
+	EmptyLen
+)
+
+// SetEmptyMode sets when Defaults treats map and slice fields as unset.
+// Use WithEmptyMode to configure a Defaulter instead.
+func SetEmptyMode(m EmptyMode) {
+	std.empty = m
+}
+
+// isZeroer is implemented by types that define their own notion of zero, such as time.Time.
+type isZeroer interface {
+	IsZero() bool
+}
+
+var (
+	presenceType = reflect.TypeFor[Presence]()
+	isZeroerType = reflect.TypeFor[isZeroer]()
+)
+
+// unsetCheck selects how a field is tested for being unset. It depends only on the
+// field type, so it is chosen once when the plan is compiled.
+type unsetCheck int
+
+const (
+	unsetZero       unsetCheck = iota // reflect.Value.IsZero
+	unsetNil                          // nil pointer
+	unsetPresence                     // Presence.IsSet
+	unsetIsZero                       // IsZero method
+	unsetCollection                   // nil, or empty under EmptyLen
+)
+
+// unsetCheckFor returns the unset check for fields of type t.
+func unsetCheckFor(t reflect.Type) unsetCheck {
+	switch {
+	case t.Kind() == reflect.Ptr:
+		return unsetNil
+	case reflect.PointerTo(t).Implements(presenceType):
+		return unsetPresence
+	case reflect.PointerTo(t).Implements(isZeroerType):
+		return unsetIsZero
+	case t.Kind() == reflect.Map || t.Kind() == reflect.Slice:
+		return unsetCollection
+	default:
+		return unsetZero
+	}
+}
+
+// isUnset checks if an addressable field value is unset according to check and the
+// Defaulter's empty mode, without boxing the value.
+func (d *Defaulter) isUnset(check unsetCheck, val reflect.Value) bool {
+	switch check {
+	case unsetNil:
+		return val.IsNil()
+	case unsetPresence:
+		return !val.Addr().Interface().(Presence).IsSet()
+	case unsetIsZero:
+		return val.Addr().Interface().(isZeroer).IsZero()
+	case unsetCollection:
+		return val.IsNil() || (d.empty == EmptyLen && val.Len() == 0)
+	default:
+		return val.IsZero()
+	}
+}
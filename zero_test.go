@@ -0,0 +1,151 @@
+package defaults
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testVersion defines its own zero: a version is unset until Major is positive
+type testVersion struct {
+	Major int
+	Minor int
+}
+
+func (v testVersion) IsZero() bool {
+	return v.Major <= 0
+}
+
+// Struct for testing the empty mode and IsZero methods
+type testEmpty struct {
+	Map     map[string]int `default:"{\"a\":1}"`
+	Slice   []string       `default:"[\"a\"]"`
+	Array   [2]int         `default:"[1,2]"`
+	Version testVersion    `default:"{\"Major\":1,\"Minor\":2}"`
+	Time    time.Time      `default:"2024-01-01T00:00:00Z"`
+}
+
+func TestEmptyMode(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		mode  EmptyMode
+		input *testEmpty
+		want  *testEmpty
+	}{
+		{
+			name:  "nil only keeps empty collections",
+			mode:  EmptyNil,
+			input: &testEmpty{Map: map[string]int{}, Slice: []string{}},
+			want: &testEmpty{
+				Map:     map[string]int{},
+				Slice:   []string{},
+				Array:   [2]int{1, 2},
+				Version: testVersion{Major: 1, Minor: 2},
+				Time:    start,
+			},
+		},
+		{
+			name:  "length fills empty collections",
+			mode:  EmptyLen,
+			input: &testEmpty{Map: map[string]int{}, Slice: []string{}},
+			want: &testEmpty{
+				Map:     map[string]int{"a": 1},
+				Slice:   []string{"a"},
+				Array:   [2]int{1, 2},
+				Version: testVersion{Major: 1, Minor: 2},
+				Time:    start,
+			},
+		},
+		{
+			name:  "IsZero method decides",
+			mode:  EmptyNil,
+			input: &testEmpty{Version: testVersion{Minor: 5}, Time: start.Add(time.Hour)},
+			want: &testEmpty{
+				Map:     map[string]int{"a": 1},
+				Slice:   []string{"a"},
+				Array:   [2]int{1, 2},
+				Version: testVersion{Major: 1, Minor: 2},
+				Time:    start.Add(time.Hour),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New(WithEmptyMode(tt.mode)).Apply(tt.input); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.input, tt.want) {
+				t.Errorf("Apply() input = %+v, want %+v", tt.input, tt.want)
+			}
+		})
+	}
+
+	SetEmptyMode(EmptyLen)
+	t.Cleanup(func() { SetEmptyMode(EmptyNil) })
+	got := testEmpty{Slice: []string{}}
+	if err := Defaults(&got); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Slice, []string{"a"}) {
+		t.Errorf("SetDefaults() Slice = %v, want [a]", got.Slice)
+	}
+}
+
+func TestUnsetCheckFor(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want unsetCheck
+	}{
+		{name: "int", typ: reflect.TypeFor[int](), want: unsetZero},
+		{name: "array", typ: reflect.TypeFor[[2]int](), want: unsetZero},
+		{name: "pointer", typ: reflect.TypeFor[*time.Time](), want: unsetNil},
+		{name: "optional", typ: reflect.TypeFor[Opt[int]](), want: unsetPresence},
+		{name: "IsZero method", typ: reflect.TypeFor[time.Time](), want: unsetIsZero},
+		{name: "map", typ: reflect.TypeFor[map[string]int](), want: unsetCollection},
+		{name: "slice", typ: reflect.TypeFor[[]int](), want: unsetCollection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unsetCheckFor(tt.typ); got != tt.want {
+				t.Errorf("unsetCheckFor(%v) = %v, want %v", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
+
+// deepEqualUnset is the zero check used before unsetCheck, kept as a benchmark baseline.
+func deepEqualUnset(val reflect.Value) bool {
+	if val.Kind() == reflect.Ptr {
+		return val.IsNil()
+	}
+	return reflect.DeepEqual(val.Interface(), reflect.Zero(val.Type()).Interface())
+}
+
+func BenchmarkIsUnset(b *testing.B) {
+	var s test
+	v := reflect.ValueOf(&s).Elem()
+	checks := make([]unsetCheck, v.NumField())
+	for i := range checks {
+		checks[i] = unsetCheckFor(v.Field(i).Type())
+	}
+
+	b.Run("unsetCheck", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for i, check := range checks {
+				std.isUnset(check, v.Field(i))
+			}
+		}
+	})
+	b.Run("DeepEqual", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for i := range checks {
+				deepEqualUnset(v.Field(i))
+			}
+		}
+	})
+}